@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/mc"
 	log "github.com/sirupsen/logrus"
 	flag "github.com/spf13/pflag"
@@ -13,6 +15,12 @@ func init() {
 	flag.BoolVarP(&help, "help", "h", false, "show this help message")
 	flag.BoolVar(&verbose, "verbose", false, "enable verbose logging")
 	flag.BoolVarP(&version, "version", "v", false, "print the version")
+	flag.Var(&console.InputMode, "console", "how to accept commands from stdin: auto, terminal, tui, stdin or none")
+	flag.BoolVar(&console.StopOnEOF, "stop-on-eof", console.StopOnEOF, "stop the server at the end of stdin in stdin console mode")
+	flag.StringVar(&console.HistoryFile, "console-history", console.HistoryFile, "file in which to save console command history")
+	flag.StringVar(&logs.Dir, "log-dir", logs.Dir, "directory in which to write server log files, or empty to disable")
+	flag.Var(&logs.OutputFormat, "log-format", "format of log output and files: text or json")
+	flag.Var(logs.Levels, "log-level", "log level, and comma-separated component=level overrides (e.g. info,rcon=debug,runner=trace)")
 
 	flag.IntP("port", "p", mc.ServerPort, "port to listen on for Minecraft client connections")
 	if err := mc.Properties().BindPFlag("server-port", flag.Lookup("port")); err != nil {
@@ -24,6 +32,7 @@ func init() {
 
 func parseFlags() {
 	flag.Parse()
+	setFormatter()
 
 	if help {
 		printVersion()
@@ -38,7 +47,7 @@ func parseFlags() {
 	}
 
 	if verbose {
-		log.SetLevel(log.DebugLevel)
+		logs.SetLevel(log.DebugLevel)
 		log.Debug("Enabled verbose logging")
 	}
 }
@@ -46,6 +55,8 @@ func parseFlags() {
 func usage() {
 	fmt.Fprintln(os.Stderr, "Usage of mc:")
 	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run \"mc rcon --help\" for the usage of the RCON client.")
 }
 
 func printVersion() {
@@ -3,13 +3,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
+	"github.com/jbhannah/gophermine/pkg/rcon"
 
 	"github.com/jbhannah/gophermine/internal/pkg/server"
 	"github.com/mattn/go-isatty"
@@ -22,11 +26,14 @@ const (
 
 	// MCVersion is the Minecraft™: Java Edition version that this release of
 	// Gophermine is compatible with.
-	MCVersion = "1.14.4"
+	MCVersion = protocol.MinecraftVersion
 
 	// MCProtocolVersion is the Minecraft protocol version number that this
 	// release of Gophermine is compatible with.
-	MCProtocolVersion = 498
+	MCProtocolVersion = protocol.Version
+
+	// TimestampFormat is the format of timestamps in log output.
+	TimestampFormat = "2006-01-02T15:04:05.000000Z-07:00"
 )
 
 var (
@@ -35,27 +42,95 @@ var (
 	version bool
 )
 
-func init() {
+// setFormatter sets the formatter of the standard logger for the log format
+// and the attached terminal, if any.
+func setFormatter() {
+	if logs.OutputFormat == logs.JSONFormat {
+		log.SetFormatter(plainFormatter())
+		return
+	}
+
 	formatter := &log.TextFormatter{
 		FullTimestamp:   true,
-		TimestampFormat: "2006-01-02T15:04:05.000000Z-07:00",
+		TimestampFormat: TimestampFormat,
 	}
 
 	if isatty.IsTerminal(os.Stdin.Fd()) {
+		formatter.ForceColors = true
 		log.SetFormatter(&console.TermFormatter{
 			TextFormatter: formatter,
 		})
 	} else {
-		log.SetFormatter(formatter)
+		log.SetFormatter(&console.ChatFormatter{
+			Formatter: formatter,
+		})
+	}
+}
+
+// plainFormatter returns a formatter for the log format without colors or
+// Minecraft formatting codes, for log files and JSON output.
+func plainFormatter() log.Formatter {
+	if logs.OutputFormat == logs.JSONFormat {
+		return &console.ChatFormatter{
+			Formatter: &log.JSONFormatter{
+				TimestampFormat: TimestampFormat,
+			},
+		}
+	}
+
+	return &console.ChatFormatter{
+		Formatter: &log.TextFormatter{
+			DisableColors:   true,
+			FullTimestamp:   true,
+			TimestampFormat: TimestampFormat,
+		},
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == RCONCommand {
+		rconMain(os.Args[2:])
+	}
+
 	parseFlags()
 
+	logFiles, err := openLogFiles()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	if err := start(); err != nil {
 		log.Fatal(err)
 	}
+
+	for _, file := range logFiles {
+		file.Close()
+	}
+}
+
+// openLogFiles rolls over the previous server log and starts writing plain
+// text log entries to logs/latest.log, alongside the terminal output, and
+// executed commands to logs/commands.log. Logging to files is disabled if the
+// log directory is empty.
+func openLogFiles() ([]io.Closer, error) {
+	if logs.Dir == "" {
+		return nil, nil
+	}
+
+	file, err := logs.OpenFile(logs.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	log.AddHook(logs.NewHook(file, plainFormatter()))
+
+	audit, err := logs.OpenAuditLog(logs.Dir, plainFormatter())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return []io.Closer{file, audit}, nil
 }
 
 func start() error {
@@ -69,6 +144,23 @@ func start() error {
 	if err := mc.LoadProperties(); err != nil {
 		return err
 	}
+
+	if err := mc.LoadOps(); err != nil {
+		return err
+	}
+
+	if err := mc.LoadWhitelist(); err != nil {
+		return err
+	}
+
+	if err := mc.LoadBans(); err != nil {
+		return err
+	}
+
+	if err := rcon.LoadUsers(); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	sigs := make(chan os.Signal, 1)
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/rcon"
+	"github.com/mattn/go-isatty"
+	flag "github.com/spf13/pflag"
+)
+
+// RCONCommand is the subcommand that runs an RCON client instead of a server.
+const RCONCommand = "rcon"
+
+// RCONPasswordEnv is the environment variable from which the RCON client reads
+// the password if it is not given as a flag.
+const RCONPasswordEnv = "RCON_PASSWORD"
+
+// RCONHistoryFile is the file to which the command history of the interactive
+// RCON client is persisted.
+const RCONHistoryFile = ".rcon_history"
+
+// rconMain connects to an RCON server and executes the command given as
+// arguments, or commands read from stdin if there are none, then exits.
+func rconMain(args []string) {
+	flags := flag.NewFlagSet(RCONCommand, flag.ContinueOnError)
+	host := flags.String("host", "localhost", "host of the RCON server")
+	port := flags.Int("port", mc.RCONPort, "port of the RCON server")
+	password := flags.String("password", os.Getenv(RCONPasswordEnv), "RCON password, or name:password of an RCON account (default $"+RCONPasswordEnv+")")
+	useTLS := flags.Bool("tls", false, "connect to the RCON server over TLS")
+	tlsCA := flags.String("tls-ca", "", "file of CA certificates with which to verify the server certificate (implies --tls)")
+	tlsCert := flags.String("tls-cert", "", "client certificate file for servers that require one (implies --tls)")
+	tlsKey := flags.String("tls-key", "", "client certificate key file (implies --tls)")
+	tlsInsecure := flags.Bool("tls-insecure", false, "do not verify the server certificate (implies --tls)")
+
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage of mc rcon: mc rcon [flags] [command]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+
+		os.Exit(2)
+	}
+
+	var config *tls.Config
+	if *useTLS || *tlsCA != "" || *tlsCert != "" || *tlsKey != "" || *tlsInsecure {
+		c, err := rcon.ClientTLSConfig(*tlsCA, *tlsCert, *tlsKey, *tlsInsecure)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		config = c
+	}
+
+	if err := runRCON(net.JoinHostPort(*host, strconv.Itoa(*port)), config, *password, flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// runRCON connects to the RCON server, over TLS if config is not nil, and
+// executes commands.
+func runRCON(addr string, config *tls.Config, password string, args []string) error {
+	var (
+		client *rcon.Client
+		err    error
+	)
+
+	if config != nil {
+		client, err = rcon.DialTLS(addr, config)
+	} else {
+		client, err = rcon.Dial(addr)
+	}
+
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Login(password); err != nil {
+		return err
+	}
+
+	ansi := isatty.IsTerminal(os.Stdout.Fd())
+
+	if len(args) > 0 {
+		return rconExecute(client, strings.Join(args, " "), ansi)
+	}
+
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		console.HistoryFile = RCONHistoryFile
+
+		term, err := console.NewTerminal(nil)
+		if err != nil {
+			return err
+		}
+		defer term.Close()
+
+		return rconREPL(client, term, ansi)
+	}
+
+	return rconREPL(client, &lineScanner{bufio.NewScanner(os.Stdin)}, ansi)
+}
+
+// rconREPL executes commands read from the reader until the end of input or
+// Ctrl-C.
+func rconREPL(client *rcon.Client, reader console.LineReader, ansi bool) error {
+	for {
+		line, err := reader.ReadLine()
+		if err == io.EOF || err == console.ErrInterrupt {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimPrefix(strings.TrimSpace(line), "/")
+		if line == "" {
+			continue
+		}
+
+		if err := rconExecute(client, line, ansi); err != nil {
+			return err
+		}
+	}
+}
+
+func rconExecute(client *rcon.Client, command string, ansi bool) error {
+	response, err := client.Execute(command)
+	if err != nil {
+		return err
+	}
+
+	if response != "" {
+		fmt.Println(console.RenderChat(response, ansi))
+	}
+
+	return nil
+}
+
+type lineScanner struct {
+	*bufio.Scanner
+}
+
+func (ls *lineScanner) ReadLine() (string, error) {
+	if !ls.Scan() {
+		if err := ls.Err(); err != nil {
+			return "", err
+		}
+
+		return "", io.EOF
+	}
+
+	return ls.Text(), nil
+}
@@ -1,11 +1,30 @@
 module github.com/jbhannah/gophermine
 
-go 1.13
+go 1.18
 
 require (
 	github.com/buger/goterm v0.0.0-20181115115552-c206103e1f37
+	github.com/chzyer/readline v1.5.1
+	github.com/fsnotify/fsnotify v1.4.7
+	github.com/gorilla/websocket v1.4.1
 	github.com/mattn/go-isatty v0.0.10
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.5.0
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
+)
+
+require (
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/magiconair/properties v1.8.1 // indirect
+	github.com/mitchellh/mapstructure v1.1.2 // indirect
+	github.com/pelletier/go-toml v1.2.0 // indirect
+	github.com/spf13/afero v1.1.2 // indirect
+	github.com/spf13/cast v1.3.0 // indirect
+	github.com/spf13/jwalterweatherman v1.0.0 // indirect
+	github.com/subosito/gotenv v1.2.0 // indirect
+	golang.org/x/sys v0.0.0-20220310020820-b874c991c1a5 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/yaml.v2 v2.2.4 // indirect
 )
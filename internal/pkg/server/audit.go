@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the fields of command audit log entries.
+const (
+	originField     = "origin"
+	permissionField = "permission_level"
+	inputField      = "input"
+	resultField     = "result"
+)
+
+// audit records an executed command, its origin, and its result or error to
+// the command audit log.
+func audit(cmd *mc.Command, result string, err error) {
+	entry := logs.Audit.WithFields(log.Fields{
+		originField:     cmd.Name(),
+		permissionField: cmd.PermissionLevel(),
+		inputField:      cmd.Line(),
+	})
+
+	if ra, ok := cmd.Origin.(interface{ RemoteAddr() net.Addr }); ok && ra.RemoteAddr() != nil {
+		entry = entry.WithField(logs.RemoteAddrField, ra.RemoteAddr().String())
+	}
+
+	if so, ok := cmd.Origin.(mc.SelectorOrigin); ok {
+		if self := so.Entity(); self != nil && self.IsPlayer() {
+			entry = entry.WithFields(log.Fields{
+				logs.PlayerField: self.Name,
+				logs.UUIDField:   self.UUID,
+			})
+		}
+	}
+
+	if err != nil {
+		entry.WithError(err).Error("Command failed")
+		return
+	}
+
+	entry.WithField(resultField, result).Info("Command executed")
+}
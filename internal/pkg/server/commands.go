@@ -0,0 +1,130 @@
+package server
+
+import (
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
+)
+
+// Flags of nodes in the Declare Commands packet.
+const (
+	nodeRoot        byte = 0x00
+	nodeLiteral     byte = 0x01
+	nodeArgument    byte = 0x02
+	nodeExecutable  byte = 0x04
+	nodeSuggestions byte = 0x10
+)
+
+// askServer is the suggestions type of arguments for which the client sends
+// Tab-Complete requests to the server.
+const askServer = "minecraft:ask_server"
+
+// DeclareCommands sends the command tree to the client, so that it can parse
+// and highlight commands as they are typed.
+func (pconn *PlayerConn) DeclareCommands(root *mc.CommandNode) error {
+	nodes := []*mc.CommandNode{root}
+	index := map[*mc.CommandNode]int32{root: 0}
+
+	for i := 0; i < len(nodes); i++ {
+		for _, child := range nodes[i].Children {
+			if _, ok := index[child]; !ok {
+				index[child] = int32(len(nodes))
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	packet := protocol.NewPacket(protocol.DeclareCommandsPacket)
+	packet.WriteVarInt(int32(len(nodes)))
+
+	for _, node := range nodes {
+		flags := nodeArgument
+		switch {
+		case node == root:
+			flags = nodeRoot
+		case node.Type == mc.LiteralArgument:
+			flags = nodeLiteral
+		case node.Type != mc.IntegerArgument && node.Type != mc.GreedyStringArgument && node.Type != mc.ComponentArgument:
+			flags |= nodeSuggestions
+		}
+
+		if node.Executable {
+			flags |= nodeExecutable
+		}
+
+		packet.WriteByte(flags)
+		packet.WriteVarInt(int32(len(node.Children)))
+
+		for _, child := range node.Children {
+			packet.WriteVarInt(index[child])
+		}
+
+		if node == root {
+			continue
+		}
+
+		packet.WriteString(node.Name)
+
+		if flags&nodeArgument != 0 {
+			writeArgumentParser(packet, node.Type)
+		}
+
+		if flags&nodeSuggestions != 0 {
+			packet.WriteString(askServer)
+		}
+	}
+
+	packet.WriteVarInt(0)
+	return pconn.WritePacket(packet)
+}
+
+// TabComplete responds to a Tab-Complete request from the client.
+func (pconn *PlayerConn) TabComplete(request *protocol.Packet, completer mc.Completer) error {
+	id, err := request.ReadVarInt()
+	if err != nil {
+		return err
+	}
+
+	text, err := request.ReadString()
+	if err != nil {
+		return err
+	}
+
+	suggestions := completer.Complete(text, len(text))
+
+	packet := protocol.NewPacket(protocol.TabCompleteResponsePacket)
+	packet.WriteVarInt(id)
+	packet.WriteVarInt(int32(suggestions.Start))
+	packet.WriteVarInt(int32(suggestions.Length))
+	packet.WriteVarInt(int32(len(suggestions.Matches)))
+
+	for _, match := range suggestions.Matches {
+		packet.WriteString(match)
+		packet.WriteBool(false)
+	}
+
+	return pconn.WritePacket(packet)
+}
+
+func writeArgumentParser(packet *protocol.Packet, at mc.ArgumentType) {
+	switch at {
+	case mc.WordArgument:
+		packet.WriteString("brigadier:string")
+		packet.WriteVarInt(0)
+	case mc.GreedyStringArgument:
+		packet.WriteString("brigadier:string")
+		packet.WriteVarInt(2)
+	case mc.IntegerArgument:
+		packet.WriteString("brigadier:integer")
+		packet.WriteByte(0)
+	case mc.PlayersArgument:
+		packet.WriteString("minecraft:entity")
+		packet.WriteByte(0x02)
+	case mc.EntitiesArgument:
+		packet.WriteString("minecraft:entity")
+		packet.WriteByte(0)
+	case mc.GameProfileArgument:
+		packet.WriteString("minecraft:game_profile")
+	case mc.ComponentArgument:
+		packet.WriteString("minecraft:component")
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+// Complete returns suggestions for the partial command input at the cursor.
+func (server *Server) Complete(input string, cursor int) *mc.Suggestions {
+	return mc.CommandTree().Suggest(input, cursor, server)
+}
+
+// PlayerNames returns the sorted names of all online players.
+func (server *Server) PlayerNames() []string {
+	players := server.mc.Players()
+	names := make([]string, len(players))
+
+	for i, pconn := range players {
+		names[i] = pconn.Player.Name
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (server *Server) completeCommand(cmd *mc.Command) string {
+	input := cmd.Rest(0)
+	suggestions := server.Complete(input, len(input))
+
+	if len(suggestions.Matches) == 0 {
+		return "No suggestions"
+	}
+
+	return strings.Join(suggestions.Apply(input), "\n")
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/runner"
+)
+
+const (
+	// LANBroadcastAddr is the multicast address to which Minecraft clients
+	// listen for servers on the local network.
+	LANBroadcastAddr = "224.0.2.60:4445"
+
+	// LANBroadcastInterval is the time between LAN broadcasts.
+	LANBroadcastInterval = 1500 * time.Millisecond
+)
+
+var lanLogger = logs.Component("lan")
+
+// LANBroadcaster periodically announces the server on the local network, the
+// same way as a world that is opened to LAN, so that clients list it under
+// LAN Worlds.
+type LANBroadcaster struct {
+	*runner.Runner
+	conn   net.Conn
+	ticker *time.Ticker
+}
+
+// NewLANBroadcaster returns a new LANBroadcaster.
+func NewLANBroadcaster(ctx context.Context) (*LANBroadcaster, error) {
+	conn, err := net.Dial("udp", LANBroadcastAddr)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open %s for LAN broadcasts: %v", LANBroadcastAddr, err)
+	}
+
+	lan := &LANBroadcaster{conn: conn}
+	lan.Runner = runner.NewRunner(ctx, lan)
+
+	return lan, nil
+}
+
+// Name returns the name of the LAN broadcaster.
+func (lan *LANBroadcaster) Name() string {
+	return "LAN broadcaster"
+}
+
+// Setup starts the broadcast ticker.
+func (lan *LANBroadcaster) Setup() {
+	lan.ticker = time.NewTicker(LANBroadcastInterval)
+	lanLogger.WithField(logs.AddrField, LANBroadcastAddr).Info("Broadcasting to LAN")
+}
+
+// Run sends a broadcast at every tick until the broadcaster is stopped.
+func (lan *LANBroadcaster) Run() {
+	lan.broadcast()
+
+	for {
+		select {
+		case <-lan.Done():
+			return
+		case <-lan.ticker.C:
+			lan.broadcast()
+		}
+	}
+}
+
+// Cleanup stops the broadcast ticker and closes the connection.
+func (lan *LANBroadcaster) Cleanup() {
+	lan.ticker.Stop()
+
+	if err := lan.conn.Close(); err != nil {
+		lanLogger.WithError(err).Error("Error closing LAN broadcast connection")
+	}
+}
+
+func (lan *LANBroadcaster) broadcast() {
+	props := mc.Properties()
+	msg := fmt.Sprintf("[MOTD]%s[/MOTD][AD]%d[/AD]", props.MOTD, props.ServerPort)
+
+	if _, err := lan.conn.Write([]byte(msg)); err != nil {
+		lanLogger.WithError(err).Debug("Error sending LAN broadcast")
+	}
+}
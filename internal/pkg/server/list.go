@@ -0,0 +1,19 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+func (server *Server) listCommand(cmd *mc.Command) string {
+	players := server.mc.Players()
+	names := make([]string, len(players))
+
+	for i, pconn := range players {
+		names[i] = pconn.Name
+	}
+
+	return fmt.Sprintf("There are %d of a max of %d players online: %s", len(players), mc.Properties().MaxPlayers, strings.Join(names, ", "))
+}
@@ -2,39 +2,251 @@ package server
 
 import (
 	"context"
-	"io"
 	"net"
+	"sync"
 
+	"github.com/jbhannah/gophermine/pkg/chat"
 	"github.com/jbhannah/gophermine/pkg/listener"
-	log "github.com/sirupsen/logrus"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
 )
 
+// NotWhitelistedMessage is the disconnect reason given to players who are not
+// on the whitelist.
+var NotWhitelistedMessage = chat.Translate(chat.NotWhitelistedKey)
+
+var mcLogger = logs.Component("minecraft")
+
 // MCServer listens for and handles incoming Minecraft client connections.
 type MCServer struct {
 	*listener.Listener
+	players map[string]*PlayerConn
+	mu      sync.RWMutex
 }
 
 // NewMCServer returns a new MCServer.
 func NewMCServer(ctx context.Context, addr string) (*MCServer, error) {
-	mc := &MCServer{}
+	srv := &MCServer{
+		players: make(map[string]*PlayerConn),
+	}
 
-	listener, err := listener.NewListener(ctx, mc, addr)
+	listener, err := listener.NewListener(ctx, srv, addr)
 	if err != nil {
 		return nil, err
 	}
 
-	mc.Listener = listener
-	return mc, nil
+	srv.Listener = listener
+	return srv, nil
 }
 
 // Name returns the name of the Minecraft server.
-func (mc *MCServer) Name() string {
+func (srv *MCServer) Name() string {
 	return "Minecraft"
 }
 
 // HandleConn handles incoming Minecraft connections.
-func (mc *MCServer) HandleConn(conn net.Conn) {
-	if _, err := io.Copy(conn, conn); err != nil {
-		log.Errorf("Error in connection: %s", err)
+func (srv *MCServer) HandleConn(conn net.Conn) {
+	pconn := NewPlayerConn(conn)
+	defer pconn.Close()
+
+	if err := pconn.Handshake(); err != nil {
+		pconn.log().WithError(err).Error("Error in handshake")
+		return
+	}
+
+	switch pconn.State {
+	case protocol.Status:
+		srv.status(pconn)
+	case protocol.Login:
+		srv.login(pconn)
+	default:
+		pconn.log().WithField("state", pconn.State.String()).Debug("Unsupported request")
+	}
+}
+
+// Players returns the connections of all online players.
+func (srv *MCServer) Players() []*PlayerConn {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	players := make([]*PlayerConn, 0, len(srv.players))
+	for _, pconn := range srv.players {
+		players = append(players, pconn)
+	}
+
+	return players
+}
+
+// Broadcast sends a chat message to all online players and logs it.
+func (srv *MCServer) Broadcast(message *chat.Component, system bool) {
+	mcLogger.Info(message.Legacy())
+
+	for _, pconn := range srv.Players() {
+		if err := pconn.SendMessage(message, system); err != nil {
+			pconn.log().WithError(err).Error("Error sending message")
+		}
+	}
+}
+
+// Tell sends a chat message to an online player, and returns false if the
+// player is not online.
+func (srv *MCServer) Tell(player *mc.Player, message *chat.Component, system bool) bool {
+	srv.mu.RLock()
+	pconn, ok := srv.players[player.UUID]
+	srv.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	if err := pconn.SendMessage(message, system); err != nil {
+		pconn.log().WithError(err).Error("Error sending message")
+	}
+
+	return true
+}
+
+// Kick disconnects an online player with the given reason, and returns false
+// if the player is not online.
+func (srv *MCServer) Kick(player *mc.Player, reason *chat.Component) bool {
+	srv.mu.RLock()
+	pconn, ok := srv.players[player.UUID]
+	srv.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	pconn.log().WithField("reason", reason.PlainText()).Info("Kicking player")
+
+	if err := pconn.Disconnect(reason); err != nil {
+		pconn.log().WithError(err).Error("Error kicking player")
+	}
+
+	return true
+}
+
+// EnforceWhitelist disconnects all online players who are not on the
+// whitelist, if the whitelist is both enabled and enforced.
+func (srv *MCServer) EnforceWhitelist() {
+	if !mc.Properties().EnforceWhitelist {
+		return
+	}
+
+	for _, pconn := range srv.Players() {
+		if !whitelisted(pconn.Player) {
+			srv.Kick(pconn.Player, NotWhitelistedMessage)
+		}
+	}
+}
+
+// EnforceBans disconnects all online players who are banned, or whose IP
+// addresses are banned.
+func (srv *MCServer) EnforceBans() {
+	for _, pconn := range srv.Players() {
+		if ban := pconn.ban(); ban != nil {
+			srv.Kick(pconn.Player, banMessage(ban))
+		}
+	}
+}
+
+// whitelisted reports whether the player may join the server, which is true
+// of every player if the whitelist is disabled, and of operators regardless.
+func whitelisted(player *mc.Player) bool {
+	return !mc.Properties().WhiteList || mc.Whitelist().Contains(player) || mc.Ops().Contains(player)
+}
+
+// banMessage returns the disconnect reason given to banned players.
+func banMessage(ban *mc.Ban) *chat.Component {
+	key := chat.BannedKey
+	if ban.IP != "" {
+		key = chat.BannedIPKey
+	}
+
+	msg := chat.Translate(key, chat.Text(ban.Reason))
+	if ban.Expires != "" && ban.Expires != mc.BanForever {
+		msg.Append(chat.Translate(chat.BanExpirationKey, chat.Text(ban.Expires)))
+	}
+
+	return msg
+}
+
+func (srv *MCServer) login(pconn *PlayerConn) {
+	player, err := pconn.LoginStart()
+	if err != nil {
+		pconn.log().WithError(err).Error("Error in login")
+		return
+	}
+
+	if ban := pconn.ban(); ban != nil {
+		pconn.log().WithField("reason", ban.Reason).Info("Disconnecting banned player")
+
+		if err := pconn.Disconnect(banMessage(ban)); err != nil {
+			pconn.log().WithError(err).Error("Error disconnecting player")
+		}
+
+		return
+	}
+
+	if !whitelisted(player) {
+		pconn.log().Info("Disconnecting player who is not whitelisted")
+
+		if err := pconn.Disconnect(NotWhitelistedMessage); err != nil {
+			pconn.log().WithError(err).Error("Error disconnecting player")
+		}
+
+		return
+	}
+
+	if err := pconn.LoginSuccess(); err != nil {
+		pconn.log().WithError(err).Error("Error in login")
+		return
+	}
+
+	pconn.Spawn()
+
+	srv.mu.Lock()
+	srv.players[player.UUID] = pconn
+	srv.mu.Unlock()
+
+	srv.Broadcast(chat.Translate(chat.PlayerJoinedKey, chat.Text(player.Name)).SetColor(chat.Yellow), true)
+
+	defer func() {
+		srv.mu.Lock()
+		if srv.players[player.UUID] == pconn {
+			delete(srv.players, player.UUID)
+		}
+		srv.mu.Unlock()
+
+		srv.Broadcast(chat.Translate(chat.PlayerLeftKey, chat.Text(player.Name)).SetColor(chat.Yellow), true)
+	}()
+
+	if err := pconn.DeclareCommands(mc.CommandTree()); err != nil {
+		pconn.log().WithError(err).Error("Error declaring commands")
+	}
+
+	completer, _ := srv.Value(mc.ServerCompleter).(mc.Completer)
+
+	for {
+		packet, err := pconn.ReadPacket()
+		if err != nil {
+			return
+		}
+
+		switch packet.ID {
+		case protocol.TabCompleteRequestPacket:
+			if completer == nil {
+				continue
+			}
+
+			if err := pconn.TabComplete(packet, completer); err != nil {
+				pconn.log().WithError(err).Error("Error responding to tab completion")
+			}
+		case protocol.PlayerPositionPacket, protocol.PlayerPositionAndRotationPacket:
+			if err := pconn.Move(packet); err != nil {
+				pconn.log().WithError(err).Warn("Invalid player position")
+			}
+		}
 	}
 }
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jbhannah/gophermine/pkg/chat"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
+)
+
+// SamplePlayers is the maximum number of online players listed in a server
+// list ping response.
+const SamplePlayers = 12
+
+type pingVersion struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+type pingSample struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type pingPlayers struct {
+	Max    int          `json:"max"`
+	Online int          `json:"online"`
+	Sample []pingSample `json:"sample,omitempty"`
+}
+
+type pingResponse struct {
+	Version     pingVersion     `json:"version"`
+	Players     pingPlayers     `json:"players"`
+	Description *chat.Component `json:"description"`
+}
+
+// status responds to a server list ping with the server's version, player
+// counts and message of the day.
+func (srv *MCServer) status(pconn *PlayerConn) {
+	for {
+		packet, err := pconn.ReadPacket()
+		if err != nil {
+			return
+		}
+
+		switch packet.ID {
+		case protocol.StatusRequestPacket:
+			err = pconn.StatusResponse(srv.pingResponse())
+		case protocol.PingPacket:
+			err = pconn.Pong(packet)
+		default:
+			err = fmt.Errorf("Invalid packet ID %#x in status state", packet.ID)
+		}
+
+		if err != nil {
+			pconn.log().WithError(err).Debug("Error in status request")
+			return
+		}
+	}
+}
+
+func (srv *MCServer) pingResponse() *pingResponse {
+	props := mc.Properties()
+	players := srv.Players()
+
+	sample := make([]pingSample, 0, SamplePlayers)
+	for _, pconn := range players {
+		if len(sample) == SamplePlayers {
+			break
+		}
+
+		sample = append(sample, pingSample{ID: pconn.UUID, Name: pconn.Name})
+	}
+
+	return &pingResponse{
+		Version: pingVersion{
+			Name:     protocol.MinecraftVersion,
+			Protocol: protocol.Version,
+		},
+		Players: pingPlayers{
+			Max:    props.MaxPlayers,
+			Online: len(players),
+			Sample: sample,
+		},
+		Description: chat.ParseLegacy(props.MOTD),
+	}
+}
+
+// StatusResponse sends the JSON status response to the client.
+func (pconn *PlayerConn) StatusResponse(response *pingResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	packet := protocol.NewPacket(protocol.StatusResponsePacket)
+	packet.WriteString(string(data))
+
+	return pconn.WritePacket(packet)
+}
+
+// Pong echoes the payload of a ping packet back to the client.
+func (pconn *PlayerConn) Pong(ping *protocol.Packet) error {
+	payload, err := ping.ReadLong()
+	if err != nil {
+		return err
+	}
+
+	packet := protocol.NewPacket(protocol.PongPacket)
+	packet.WriteLong(payload)
+
+	return pconn.WritePacket(packet)
+}
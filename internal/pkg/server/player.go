@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+
+	"github.com/jbhannah/gophermine/pkg/chat"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
+	log "github.com/sirupsen/logrus"
+)
+
+// PlayerConn is an open Minecraft client connection.
+type PlayerConn struct {
+	net.Conn
+	*mc.Player
+	State  protocol.State
+	reader *bufio.Reader
+	mu     sync.Mutex
+
+	position mc.Position
+	gameMode mc.GameMode
+	entityMu sync.RWMutex
+}
+
+// NewPlayerConn wraps a new network connection in the Handshaking state.
+func NewPlayerConn(conn net.Conn) *PlayerConn {
+	return &PlayerConn{
+		Conn:     conn,
+		State:    protocol.Handshaking,
+		reader:   bufio.NewReader(conn),
+		position: mc.WorldSpawn,
+	}
+}
+
+// Entity returns a snapshot of the player's state in the world. Players have
+// no experience, so their level is always 0.
+func (pconn *PlayerConn) Entity() *mc.Entity {
+	pconn.entityMu.RLock()
+	defer pconn.entityMu.RUnlock()
+
+	return mc.NewPlayerEntity(pconn.Player, pconn.position, pconn.gameMode)
+}
+
+// Spawn places the player at the world spawn in the default game mode, as
+// they join the game.
+func (pconn *PlayerConn) Spawn() {
+	pconn.entityMu.Lock()
+	defer pconn.entityMu.Unlock()
+
+	pconn.position = mc.WorldSpawn
+	pconn.gameMode = mc.Properties().GameMode
+}
+
+// Move updates the position of the player from a Player Position or Player
+// Position And Rotation packet, which both begin with the X, Y and Z
+// coordinates of the player's feet.
+func (pconn *PlayerConn) Move(packet *protocol.Packet) error {
+	var pos mc.Position
+
+	for _, coord := range []*float64{&pos.X, &pos.Y, &pos.Z} {
+		v, err := packet.ReadDouble()
+		if err != nil {
+			return err
+		}
+
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Invalid coordinate %v", v)
+		}
+
+		*coord = v
+	}
+
+	pconn.entityMu.Lock()
+	defer pconn.entityMu.Unlock()
+
+	pconn.position = pos
+	return nil
+}
+
+// ban returns the ban of the player or of the IP address of the connection,
+// or nil if neither is banned.
+func (pconn *PlayerConn) ban() *mc.Ban {
+	if ban := mc.BannedPlayers().Player(pconn.Player); ban != nil {
+		return ban
+	}
+
+	host, _, err := net.SplitHostPort(pconn.RemoteAddr().String())
+	if err != nil {
+		return nil
+	}
+
+	return mc.BannedIPs().IP(host)
+}
+
+// log returns a log entry with the remote address of the connection and, once
+// the player has logged in, the player's name and UUID.
+func (pconn *PlayerConn) log() *log.Entry {
+	entry := mcLogger.WithField(logs.RemoteAddrField, pconn.RemoteAddr().String())
+
+	if pconn.Player != nil {
+		entry = entry.WithFields(log.Fields{
+			logs.PlayerField: pconn.Name,
+			logs.UUIDField:   pconn.UUID,
+		})
+	}
+
+	return entry
+}
+
+// ReadPacket reads the next packet from the connection.
+func (pconn *PlayerConn) ReadPacket() (*protocol.Packet, error) {
+	return protocol.ReadPacket(pconn.reader)
+}
+
+// WritePacket writes a packet to the connection.
+func (pconn *PlayerConn) WritePacket(packet *protocol.Packet) error {
+	pconn.mu.Lock()
+	defer pconn.mu.Unlock()
+
+	_, err := pconn.Conn.Write(packet.Bytes())
+	return err
+}
+
+// Handshake reads the initial Handshake packet from the client and switches to
+// the requested state.
+func (pconn *PlayerConn) Handshake() error {
+	packet, err := pconn.ReadPacket()
+	if err != nil {
+		return err
+	}
+
+	if packet.ID != protocol.HandshakePacket {
+		return fmt.Errorf("Invalid packet ID %#x, expected handshake", packet.ID)
+	}
+
+	if _, err := packet.ReadVarInt(); err != nil {
+		return err
+	}
+
+	if _, err := packet.ReadString(); err != nil {
+		return err
+	}
+
+	if _, err := packet.ReadUnsignedShort(); err != nil {
+		return err
+	}
+
+	next, err := packet.ReadVarInt()
+	if err != nil {
+		return err
+	}
+
+	switch state := protocol.State(next); state {
+	case protocol.Status, protocol.Login:
+		pconn.State = state
+	default:
+		return fmt.Errorf("Invalid next state %d", next)
+	}
+
+	return nil
+}
+
+// LoginStart reads the Login Start packet from the client and identifies the
+// connecting player.
+func (pconn *PlayerConn) LoginStart() (*mc.Player, error) {
+	packet, err := pconn.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	if packet.ID != protocol.LoginStartPacket {
+		return nil, fmt.Errorf("Invalid packet ID %#x, expected login start", packet.ID)
+	}
+
+	name, err := packet.ReadString()
+	if err != nil {
+		return nil, err
+	}
+
+	pconn.Player = mc.NewOfflinePlayer(name)
+	return pconn.Player, nil
+}
+
+// LoginSuccess completes the login of the connecting player and switches the
+// connection to the Play state.
+func (pconn *PlayerConn) LoginSuccess() error {
+	packet := protocol.NewPacket(protocol.LoginSuccessPacket)
+	packet.WriteString(pconn.Player.UUID)
+	packet.WriteString(pconn.Player.Name)
+
+	if err := pconn.WritePacket(packet); err != nil {
+		return err
+	}
+
+	pconn.State = protocol.Play
+	return nil
+}
+
+// Disconnect sends a disconnect packet with the given reason to the client and
+// closes the connection.
+func (pconn *PlayerConn) Disconnect(reason *chat.Component) error {
+	defer pconn.Close()
+
+	var id int32
+	switch pconn.State {
+	case protocol.Login:
+		id = protocol.LoginDisconnectPacket
+	case protocol.Play:
+		id = protocol.PlayDisconnectPacket
+	default:
+		return nil
+	}
+
+	packet := protocol.NewPacket(id)
+	packet.WriteString(reason.JSON())
+
+	return pconn.WritePacket(packet)
+}
+
+// SendMessage sends a chat message to the client. System messages are shown
+// in the chat box, but are not affected by the player's chat settings.
+func (pconn *PlayerConn) SendMessage(message *chat.Component, system bool) error {
+	packet := protocol.NewPacket(protocol.ChatMessagePacket)
+	packet.WriteString(message.JSON())
+
+	if system {
+		packet.WriteByte(1)
+	} else {
+		packet.WriteByte(0)
+	}
+
+	return pconn.WritePacket(packet)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"net"
+
+	"github.com/jbhannah/gophermine/pkg/listener"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/protocol"
+	"github.com/jbhannah/gophermine/pkg/query"
+)
+
+var queryLogger = logs.Component("query")
+
+// QueryServer responds to GameSpy4 Query requests over UDP with the status of
+// the Minecraft server.
+type QueryServer struct {
+	*listener.PacketListener
+	mc         *MCServer
+	challenges *query.Challenges
+}
+
+// NewQueryServer returns a new QueryServer that reports the status of the
+// Minecraft server.
+func NewQueryServer(ctx context.Context, addr string, mcServer *MCServer) (*QueryServer, error) {
+	srv := &QueryServer{
+		mc:         mcServer,
+		challenges: query.NewChallenges(),
+	}
+
+	listener, err := listener.NewPacketListener(ctx, srv, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.PacketListener = listener
+	return srv, nil
+}
+
+// Name returns the name of the Query server.
+func (srv *QueryServer) Name() string {
+	return "Query"
+}
+
+// HandlePacket responds to a handshake request with a new challenge token, and
+// to a stat request with a valid challenge token with the server's status.
+// Invalid requests are ignored.
+func (srv *QueryServer) HandlePacket(conn net.PacketConn, addr net.Addr, data []byte) {
+	entry := queryLogger.WithField(logs.RemoteAddrField, addr.String())
+
+	req, err := query.ParseRequest(data)
+	if err != nil {
+		entry.WithError(err).Debug("Ignoring invalid Query request")
+		return
+	}
+
+	var resp []byte
+
+	switch req.Type {
+	case query.HandshakePacket:
+		token, err := srv.challenges.Issue(addr)
+		if err != nil {
+			entry.WithError(err).Error("Could not issue Query challenge token")
+			return
+		}
+
+		resp = query.HandshakeResponse(req.SessionID, token)
+	case query.StatPacket:
+		if !srv.challenges.Verify(addr, req.Token) {
+			entry.Debug("Ignoring Query request with invalid challenge token")
+			return
+		}
+
+		if req.Full {
+			resp = srv.stat().Full(req.SessionID)
+		} else {
+			resp = srv.stat().Basic(req.SessionID)
+		}
+	}
+
+	if _, err := conn.WriteTo(resp, addr); err != nil {
+		entry.WithError(err).Error("Error responding to Query request")
+	}
+}
+
+func (srv *QueryServer) stat() *query.Stat {
+	props := mc.Properties()
+
+	hostIP := props.ServerIP
+	if hostIP == "" {
+		hostIP = "0.0.0.0"
+	}
+
+	players := srv.mc.Players()
+	names := make([]string, len(players))
+	for i, pconn := range players {
+		names[i] = pconn.Name
+	}
+
+	return &query.Stat{
+		MOTD:       props.MOTD,
+		Version:    protocol.MinecraftVersion,
+		Map:        props.LevelName,
+		MaxPlayers: props.MaxPlayers,
+		HostIP:     hostIP,
+		HostPort:   props.ServerPort,
+		Players:    names,
+	}
+}
@@ -2,29 +2,55 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/jbhannah/gophermine/pkg/listener"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
 	"github.com/jbhannah/gophermine/pkg/rcon"
-
-	log "github.com/sirupsen/logrus"
 )
 
+const rconLockoutsUsage = "Usage: rcon-lockouts [list|clear [address]]"
+
+var rconLogger = logs.Component("rcon")
+
 // RCONServer listens for and handles incoming RCON connections.
 type RCONServer struct {
 	*listener.Listener
 }
 
-// NewRCONServer returns a new RCONServer.
+// NewRCONServer returns a new RCONServer. Connections are wrapped in TLS if
+// the rcon.tls.cert-file and rcon.tls.key-file properties are set.
 func NewRCONServer(ctx context.Context, addr string) (*RCONServer, error) {
 	srv := &RCONServer{}
 
-	listener, err := listener.NewListener(ctx, srv, addr)
+	props := mc.Properties()
+	rcon.Lockouts().Configure(props.RCON.MaxLoginAttempts, time.Duration(props.RCON.LockoutDuration)*time.Second)
+
+	var (
+		l   *listener.Listener
+		err error
+	)
+
+	if props.RCONTLS() {
+		config, cerr := rcon.ServerTLSConfig(props.RCON.TLS.CertFile, props.RCON.TLS.KeyFile, props.RCON.TLS.ClientCAFile)
+		if cerr != nil {
+			return nil, cerr
+		}
+
+		l, err = listener.NewTLSListener(ctx, srv, addr, config)
+	} else {
+		l, err = listener.NewListener(ctx, srv, addr)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	srv.Listener = listener
+	srv.Listener = l
 	return srv, nil
 }
 
@@ -37,7 +63,7 @@ func (srv *RCONServer) Name() string {
 func (srv *RCONServer) HandleConn(conn net.Conn) {
 	rconn, err := rcon.NewConn(srv.Context, conn)
 	if err != nil {
-		log.Errorf("Could not initialize RCON console for %s: %s", conn.RemoteAddr(), err)
+		rconLogger.WithField(logs.RemoteAddrField, conn.RemoteAddr().String()).WithError(err).Error("Could not initialize RCON console")
 		conn.Close()
 		return
 	}
@@ -45,3 +71,35 @@ func (srv *RCONServer) HandleConn(conn net.Conn) {
 	<-rconn.Start()
 	<-rconn.Stopped()
 }
+
+func (server *Server) rconLockoutsCommand(cmd *mc.Command) string {
+	throttle := rcon.Lockouts()
+
+	if len(cmd.Args) == 0 || (cmd.Args[0] == "list" && len(cmd.Args) == 1) {
+		lockouts := throttle.List()
+		if len(lockouts) == 0 {
+			return "There are no locked out RCON clients"
+		}
+
+		clients := make([]string, len(lockouts))
+		for i, lockout := range lockouts {
+			clients[i] = fmt.Sprintf("%s (%s remaining)", lockout.Host, time.Until(lockout.Until).Round(time.Second))
+		}
+
+		return fmt.Sprintf("There are %d locked out RCON clients: %s", len(lockouts), strings.Join(clients, ", "))
+	}
+
+	if cmd.Args[0] != "clear" || len(cmd.Args) > 2 {
+		return rconLockoutsUsage
+	}
+
+	if len(cmd.Args) == 1 {
+		return fmt.Sprintf("Cleared %d RCON lockouts", throttle.ClearAll())
+	}
+
+	if !throttle.Clear(cmd.Args[1]) {
+		return fmt.Sprintf("%s has no failed RCON login attempts", cmd.Args[1])
+	}
+
+	return fmt.Sprintf("Cleared RCON lockout for %s", cmd.Args[1])
+}
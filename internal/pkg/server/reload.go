@@ -0,0 +1,232 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/rcon"
+)
+
+// ReloadFiles are the configuration files that are reloaded by the /reload
+// command and when they change.
+var ReloadFiles = []string{
+	mc.PropertiesFile,
+	mc.OpsFile,
+	mc.WhitelistFile,
+	mc.BannedPlayersFile,
+	mc.BannedIPsFile,
+	rcon.UsersFile,
+}
+
+// listenerAddrs are the addresses of the network listeners in the current
+// configuration. The addresses of disabled listeners are empty.
+type listenerAddrs struct {
+	mc        string
+	query     string
+	rcon      string
+	websocket string
+	lan       bool
+}
+
+// currentListenerAddrs returns the addresses of the network listeners in the
+// current configuration. The RCON listeners are disabled if there is no way
+// to log in to them.
+func currentListenerAddrs() listenerAddrs {
+	props := mc.Properties()
+
+	addrs := listenerAddrs{
+		mc:    props.ServerAddr(),
+		query: props.QueryAddr(),
+		lan:   props.BroadcastLAN,
+	}
+
+	if props.RCON.Password != "" || rcon.Users().Len() > 0 {
+		addrs.rcon = props.RCONAddr()
+		addrs.websocket = props.RCONWebSocketAddr()
+	}
+
+	return addrs
+}
+
+func (server *Server) reloadCommand(cmd *mc.Command) string {
+	return server.reload(ReloadFiles...)
+}
+
+// reload reloads the given configuration files and applies their changes to
+// the running server, and returns a summary of the changes. Invalid files are
+// not applied.
+func (server *Server) reload(files ...string) string {
+	server.reloading.Lock()
+	defer server.reloading.Unlock()
+
+	if server.Err() != nil {
+		return "The server is stopping"
+	}
+
+	var resp []string
+	changes := &mc.PropertyChanges{}
+
+	for _, file := range files {
+		var err error
+
+		switch file {
+		case mc.PropertiesFile:
+			var c *mc.PropertyChanges
+			if c, err = mc.ReloadProperties(); err == nil {
+				changes = c
+			}
+		case mc.OpsFile:
+			err = mc.Ops().Load()
+		case mc.WhitelistFile:
+			err = mc.Whitelist().Load()
+		case mc.BannedPlayersFile:
+			err = mc.BannedPlayers().Load()
+		case mc.BannedIPsFile:
+			err = mc.BannedIPs().Load()
+		case rcon.UsersFile:
+			err = rcon.LoadUsers()
+		default:
+			continue
+		}
+
+		if err != nil {
+			resp = append(resp, fmt.Sprintf("Could not reload %s: %s", file, err))
+		} else {
+			resp = append(resp, fmt.Sprintf("Reloaded %s", file))
+		}
+	}
+
+	if len(changes.Live) > 0 {
+		resp = append(resp, fmt.Sprintf("Applied changes to %s", strings.Join(changes.Live, ", ")))
+	}
+
+	props := mc.Properties()
+	rcon.Lockouts().Configure(props.RCON.MaxLoginAttempts, time.Duration(props.RCON.LockoutDuration)*time.Second)
+
+	resp = append(resp, server.rebind()...)
+	server.mc.EnforceBans()
+	server.mc.EnforceWhitelist()
+
+	if len(changes.Restart) > 0 {
+		resp = append(resp, fmt.Sprintf("Restart the server to apply changes to %s", strings.Join(changes.Restart, ", ")))
+	}
+
+	return strings.Join(resp, "\n")
+}
+
+// rebind moves, starts or stops each network listener whose address in the
+// current configuration differs from the address it was bound to, without
+// disconnecting the players or RCON clients of listeners that are moved. It
+// returns a summary of the changes. The listeners are replaced only once they
+// have started or stopped, as they log while doing so, and log lines redraw
+// the status bar.
+func (server *Server) rebind() []string {
+	addrs := currentListenerAddrs()
+	resp := make([]string, 0)
+
+	server.mu.RLock()
+	query, lan, rcon, ws := server.query, server.lan, server.rcon, server.websocket
+	server.mu.RUnlock()
+
+	if addrs.mc != server.addrs.mc {
+		if err := server.mc.Rebind(addrs.mc); err != nil {
+			resp = append(resp, err.Error())
+		} else {
+			server.addrs.mc = addrs.mc
+			resp = append(resp, fmt.Sprintf("Moved %s listener to %s", server.mc.Name(), addrs.mc))
+		}
+	}
+
+	if addrs.query != server.addrs.query {
+		if query != nil {
+			<-query.Stop()
+			query = nil
+			resp = append(resp, "Stopped Query listener")
+		}
+
+		server.addrs.query = ""
+
+		if addrs.query != "" {
+			if q, err := NewQueryServer(server.Context, addrs.query, server.mc); err != nil {
+				resp = append(resp, err.Error())
+			} else {
+				<-q.Start()
+				query = q
+				server.addrs.query = addrs.query
+				resp = append(resp, fmt.Sprintf("Started %s listener on %s", query.Name(), addrs.query))
+			}
+		}
+	}
+
+	if addrs.rcon != server.addrs.rcon {
+		switch {
+		case rcon != nil && addrs.rcon != "":
+			if err := rcon.Rebind(addrs.rcon); err != nil {
+				resp = append(resp, err.Error())
+			} else {
+				server.addrs.rcon = addrs.rcon
+				resp = append(resp, fmt.Sprintf("Moved %s listener to %s", rcon.Name(), addrs.rcon))
+			}
+		case rcon != nil:
+			<-rcon.Stop()
+			rcon = nil
+			server.addrs.rcon = ""
+			resp = append(resp, "Stopped RCON listener")
+		default:
+			if r, err := NewRCONServer(server.Context, addrs.rcon); err != nil {
+				resp = append(resp, err.Error())
+			} else {
+				<-r.Start()
+				rcon = r
+				server.addrs.rcon = addrs.rcon
+				resp = append(resp, fmt.Sprintf("Started %s listener on %s", rcon.Name(), addrs.rcon))
+			}
+		}
+	}
+
+	if addrs.websocket != server.addrs.websocket {
+		if ws != nil {
+			<-ws.Stop()
+			ws = nil
+			resp = append(resp, "Stopped RCON WebSocket listener")
+		}
+
+		server.addrs.websocket = ""
+
+		if addrs.websocket != "" {
+			if w, err := NewWebSocketServer(server.Context, addrs.websocket); err != nil {
+				resp = append(resp, err.Error())
+			} else {
+				<-w.Start()
+				ws = w
+				server.addrs.websocket = addrs.websocket
+				resp = append(resp, fmt.Sprintf("Started %s listener on %s", ws.Name(), addrs.websocket))
+			}
+		}
+	}
+
+	if addrs.lan != server.addrs.lan {
+		if lan != nil {
+			<-lan.Stop()
+			lan = nil
+			server.addrs.lan = false
+			resp = append(resp, "Stopped LAN broadcasts")
+		} else if l, err := NewLANBroadcaster(server.Context); err != nil {
+			resp = append(resp, err.Error())
+		} else {
+			<-l.Start()
+			lan = l
+			server.addrs.lan = true
+			resp = append(resp, "Started LAN broadcasts")
+		}
+	}
+
+	server.mu.Lock()
+	server.query, server.lan, server.rcon, server.websocket = query, lan, rcon, ws
+	server.mu.Unlock()
+
+	server.publishStatus(rcon)
+	return resp
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/jbhannah/gophermine/pkg/chat"
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+const (
+	sayUsage     = "Usage: say <message>"
+	tellrawUsage = "Usage: tellraw <targets> <message>"
+
+	// ServerSenderName is the name shown as the sender of messages from the
+	// server console or RCON.
+	ServerSenderName = "Server"
+)
+
+func (server *Server) sayCommand(cmd *mc.Command) string {
+	message := cmd.Rest(0)
+	if message == "" {
+		return sayUsage
+	}
+
+	server.mc.Broadcast(chat.Translate(chat.AnnouncementKey, chat.Text(ServerSenderName), chat.Text(message)), false)
+	return ""
+}
+
+func (server *Server) tellrawCommand(cmd *mc.Command) (string, error) {
+	if len(cmd.Args) < 2 {
+		return tellrawUsage, nil
+	}
+
+	players, err := server.selectPlayers(cmd, cmd.Args[0])
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	message, err := chat.Parse([]byte(cmd.Rest(1)))
+	if err != nil {
+		return fmt.Sprintf("Invalid chat component: %s", err), nil
+	}
+
+	for _, player := range players {
+		server.mc.Tell(player, message, true)
+	}
+
+	return "", nil
+}
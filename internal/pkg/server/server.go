@@ -5,30 +5,55 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jbhannah/gophermine/internal/pkg/utils"
 
 	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/mc"
 	"github.com/jbhannah/gophermine/pkg/runner"
 
-	"github.com/mattn/go-isatty"
 	log "github.com/sirupsen/logrus"
 )
 
 // TickDuration is the length of a single world tick (50ms).
 const TickDuration = 50 * time.Millisecond
 
+var logger = logs.Component("server")
+
 // Server defines the overarching server, managing the world instance, network
 // listeners, and communication between them all.
 type Server struct {
 	*runner.Runner
-	commands <-chan *mc.Command
-	console  *console.Console
-	mc       *MCServer
-	rcon     *RCONServer
-	ticker   *time.Ticker
+	commands  <-chan *mc.Command
+	console   *console.Console
+	terminal  *console.Terminal
+	tui       *console.TUI
+	mc        *MCServer
+	query     *QueryServer
+	lan       *LANBroadcaster
+	rcon      *RCONServer
+	websocket *WebSocketServer
+	watcher   *FileWatcher
+	addrs     listenerAddrs
+	startTime time.Time
+	ticker    *time.Ticker
+	ticks     *tickStats
+
+	// mu guards the listeners that are replaced when the configuration is
+	// reloaded. It is only held while they are swapped, never while they
+	// start or stop.
+	mu sync.RWMutex
+
+	// reloading serializes reloads with the startup and shutdown of the
+	// listeners.
+	reloading sync.Mutex
+
+	// status holds the listener addresses shown in the status bar, which is
+	// redrawn by every log line and so must not wait for any lock.
+	status atomic.Value
 }
 
 // NewServer instantiates a new server.
@@ -36,42 +61,107 @@ func NewServer(ctx context.Context) (*Server, error) {
 	cmds := make(chan *mc.Command)
 
 	server := &Server{
-		commands: cmds,
-		ticker:   time.NewTicker(TickDuration),
+		commands:  cmds,
+		startTime: time.Now(),
+		ticker:    time.NewTicker(TickDuration),
+		ticks:     &tickStats{},
 	}
 
 	ctx = context.WithValue(ctx, mc.ServerCommands, cmds)
+	ctx = context.WithValue(ctx, mc.ServerCompleter, mc.Completer(server))
 	server.Runner = runner.NewRunner(ctx, server)
 
-	if isatty.IsTerminal(os.Stdin.Fd()) {
+	switch console.InputMode.Resolve() {
+	case console.TerminalMode:
 		writer := &utils.LineWriter{
 			Writer: log.StandardLogger().WriterLevel(log.InfoLevel),
 		}
 
-		if cons, err := console.NewConsole(server.Context, "Console", os.Stdin, writer); err != nil {
+		if cons, term, err := console.NewTerminalConsole(server.Context, "Console", writer); err != nil {
+			return nil, err
+		} else {
+			server.console = cons
+			server.terminal = term
+			logs.SetOutput(term.Stderr())
+		}
+	case console.TUIMode:
+		writer := &utils.LineWriter{
+			Writer: log.StandardLogger().WriterLevel(log.InfoLevel),
+		}
+
+		if cons, tui, err := console.NewTUIConsole(server.Context, "Console", writer, server.Status); err != nil {
+			return nil, err
+		} else {
+			server.console = cons
+			server.tui = tui
+			logs.SetOutput(tui)
+
+			if tf, ok := log.StandardLogger().Formatter.(*console.TermFormatter); ok {
+				log.SetFormatter(&console.ChatFormatter{
+					Formatter: tf.TextFormatter,
+					ANSI:      true,
+				})
+			}
+		}
+	case console.StdinMode:
+		writer := &utils.LineWriter{
+			Writer: os.Stdout,
+		}
+
+		if cons, err := console.NewStdinConsole(server.Context, "Console", os.Stdin, writer, console.StopOnEOF); err != nil {
 			return nil, err
 		} else {
 			server.console = cons
 		}
 	}
 
-	if mcServer, err := NewMCServer(server.Context, mc.Properties().ServerAddr()); err != nil {
+	server.addrs = currentListenerAddrs()
+
+	if mcServer, err := NewMCServer(server.Context, server.addrs.mc); err != nil {
 		return nil, err
 	} else {
 		server.mc = mcServer
 	}
 
-	rconAddr := mc.Properties().RCONAddr()
-	rconPass := mc.Properties().RCON.Password
+	if server.addrs.query != "" {
+		if query, err := NewQueryServer(server.Context, server.addrs.query, server.mc); err != nil {
+			return nil, err
+		} else {
+			server.query = query
+		}
+	}
+
+	if server.addrs.lan {
+		if lan, err := NewLANBroadcaster(server.Context); err != nil {
+			return nil, err
+		} else {
+			server.lan = lan
+		}
+	}
 
-	if rconAddr != "" && rconPass != "" {
-		if rcon, err := NewRCONServer(server.Context, rconAddr); err != nil {
+	if server.addrs.rcon != "" {
+		if rcon, err := NewRCONServer(server.Context, server.addrs.rcon); err != nil {
 			return nil, err
 		} else {
 			server.rcon = rcon
 		}
 	}
 
+	if server.addrs.websocket != "" {
+		if ws, err := NewWebSocketServer(server.Context, server.addrs.websocket); err != nil {
+			return nil, err
+		} else {
+			server.websocket = ws
+		}
+	}
+
+	if watcher, err := NewFileWatcher(server.Context, server.reload, ReloadFiles...); err != nil {
+		logger.WithError(err).Warn("Configuration files will only be reloaded by the reload command")
+	} else {
+		server.watcher = watcher
+	}
+
+	server.publishStatus(server.rcon)
 	return server, nil
 }
 
@@ -80,11 +170,33 @@ func (server *Server) Name() string {
 	return "Gophermine"
 }
 
-// Setup starts the server's network listeners.
+// Setup starts the server's network listeners. Reloads wait until they have
+// started.
 func (server *Server) Setup() {
+	server.reloading.Lock()
+	defer server.reloading.Unlock()
+
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
+	if server.query != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.query.Start()
+		}(wg)
+	}
+
+	if server.lan != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.lan.Start()
+		}(wg)
+	}
+
 	if server.rcon != nil {
 		wg.Add(1)
 
@@ -94,6 +206,24 @@ func (server *Server) Setup() {
 		}(wg)
 	}
 
+	if server.websocket != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.websocket.Start()
+		}(wg)
+	}
+
+	if server.watcher != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.watcher.Start()
+		}(wg)
+	}
+
 	if server.console != nil {
 		wg.Add(1)
 
@@ -119,18 +249,55 @@ func (server *Server) Run() {
 			return
 		case cmd := <-server.commands:
 			go server.handleCommand(cmd)
-		case <-server.ticker.C:
+		case scheduled := <-server.ticker.C:
+			server.tick(scheduled)
 		}
 	}
 }
 
-// Cleanup stops the server's ticker and network listeners.
+// tick runs a world tick that was scheduled at the given time. Its duration is
+// measured from that time, so that the time the tick spent waiting for the
+// server loop, or for the Go scheduler when the process is overloaded, counts
+// towards it in the same way as time spent processing it.
+func (server *Server) tick(scheduled time.Time) {
+	start := time.Now()
+	server.ticks.record(start, time.Since(scheduled))
+}
+
+// Cleanup stops the server's ticker and network listeners. The file watcher
+// is stopped first, and any reload in progress is finished, before the
+// listeners are stopped.
 func (server *Server) Cleanup() {
 	server.ticker.Stop()
 
+	if server.watcher != nil {
+		<-server.watcher.Stopped()
+	}
+
+	server.reloading.Lock()
+	defer server.reloading.Unlock()
+
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
+	if server.query != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.query.Stopped()
+		}(wg)
+	}
+
+	if server.lan != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.lan.Stopped()
+		}(wg)
+	}
+
 	if server.rcon != nil {
 		wg.Add(1)
 
@@ -140,12 +307,25 @@ func (server *Server) Cleanup() {
 		}(wg)
 	}
 
+	if server.websocket != nil {
+		wg.Add(1)
+
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			<-server.websocket.Stopped()
+		}(wg)
+	}
+
 	if server.console != nil {
 		wg.Add(1)
 
 		go func(wg *sync.WaitGroup) {
 			defer wg.Done()
 			<-server.console.Stopped()
+
+			if server.terminal != nil || server.tui != nil {
+				logs.SetOutput(os.Stderr)
+			}
 		}(wg)
 	}
 
@@ -158,12 +338,54 @@ func (server *Server) Cleanup() {
 }
 
 func (server *Server) handleCommand(cmd *mc.Command) {
-	if _, err := cmd.Write([]byte(fmt.Sprintf("Command received: %s", cmd))); err != nil {
-		log.Errorf("Error responding to command %s: %s", cmd, err)
+	defer cmd.Finish()
+
+	var resp string
+	var err error
+
+	permitted := cmd.Permitted()
+	if permitted {
+		resp, err = server.runCommand(cmd)
+	} else {
+		resp = "You do not have permission to use this command"
+	}
+
+	audit(cmd, resp, err)
+
+	if err != nil {
+		logger.WithField("command", cmd.String()).WithError(err).Error("Error running command")
+		resp = fmt.Sprintf("An unexpected error occurred trying to execute that command: %s", err)
+	}
+
+	if _, err := cmd.Write([]byte(resp)); err != nil {
+		logger.WithField("command", cmd.String()).WithError(err).Error("Error responding to command")
 	}
 
 	switch cmd.CommandType {
 	case mc.StopCommand:
-		server.Stop()
+		if permitted {
+			server.Stop()
+		}
+	}
+}
+
+func (server *Server) runCommand(cmd *mc.Command) (string, error) {
+	switch cmd.CommandType {
+	case mc.CompleteCommand:
+		return server.completeCommand(cmd), nil
+	case mc.ListCommand:
+		return server.listCommand(cmd), nil
+	case mc.RCONLockoutsCommand:
+		return server.rconLockoutsCommand(cmd), nil
+	case mc.ReloadCommand:
+		return server.reloadCommand(cmd), nil
+	case mc.SayCommand:
+		return server.sayCommand(cmd), nil
+	case mc.TellrawCommand:
+		return server.tellrawCommand(cmd)
+	case mc.WhitelistCommand:
+		return server.whitelistCommand(cmd)
 	}
+
+	return fmt.Sprintf("Command received: %s", cmd), nil
 }
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Status returns a one-line summary of the running server for display in the
+// status bar of the full-screen console.
+func (server *Server) Status() string {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	status := []string{
+		fmt.Sprintf("Up %s", time.Since(server.startTime).Round(time.Second)),
+		fmt.Sprintf("TPS %.1f (%.2f mspt)", server.ticks.TPS(), server.ticks.MSPT()),
+	}
+
+	// The console may be started before the network listeners are created.
+	if addrs, ok := server.status.Load().([]string); ok {
+		status = append(status,
+			fmt.Sprintf("Players %d", len(server.mc.Players())),
+			strings.Join(addrs, " "))
+	}
+
+	status = append(status, fmt.Sprintf("Heap %.1f MiB", float64(mem.HeapAlloc)/(1<<20)))
+	return strings.Join(status, " | ")
+}
+
+// publishStatus publishes the addresses of the Minecraft and RCON listeners
+// for the status bar.
+func (server *Server) publishStatus(rcon *RCONServer) {
+	addrs := []string{fmt.Sprintf("MC %s", server.mc.Addr())}
+	if rcon != nil {
+		addrs = append(addrs, fmt.Sprintf("RCON %s", rcon.Addr()))
+	}
+
+	server.status.Store(addrs)
+}
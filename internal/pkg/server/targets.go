@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+var errNoPlayerFound = errors.New("No player was found")
+
+// entities returns snapshots of all online players.
+func (server *Server) entities() []*mc.Entity {
+	players := server.mc.Players()
+	entities := make([]*mc.Entity, len(players))
+
+	for i, pconn := range players {
+		entities[i] = pconn.Entity()
+	}
+
+	return entities
+}
+
+// selectPlayers resolves a target argument of a command to one or more online
+// players.
+func (server *Server) selectPlayers(cmd *mc.Command, arg string) ([]*mc.Player, error) {
+	ctx := mc.NewSelectorContext(cmd.Origin)
+	var entities []*mc.Entity
+
+	if mc.IsSelector(arg) {
+		sel, err := mc.ParseSelector(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !sel.PlayersOnly() {
+			return nil, fmt.Errorf("Only players may be affected by this command, but the provided selector includes entities")
+		}
+
+		entities = sel.Select(ctx, server.entities())
+	} else {
+		var err error
+		if entities, err = mc.SelectTargets(arg, ctx, server.entities()); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(entities) == 0 {
+		return nil, errNoPlayerFound
+	}
+
+	players := make([]*mc.Player, len(entities))
+	for i, entity := range entities {
+		players[i] = entity.Player()
+	}
+
+	return players, nil
+}
+
+// selectProfiles resolves a target argument of a command to players who may
+// be offline: selectors match online players, while names are resolved
+// whether or not the player is online.
+func (server *Server) selectProfiles(cmd *mc.Command, arg string) ([]*mc.Player, error) {
+	if mc.IsSelector(arg) {
+		return server.selectPlayers(cmd, arg)
+	}
+
+	if players, err := server.selectPlayers(cmd, arg); err == nil {
+		return players, nil
+	}
+
+	return []*mc.Player{mc.NewOfflinePlayer(arg)}, nil
+}
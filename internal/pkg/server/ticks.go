@@ -0,0 +1,78 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// TickSamples is the number of recent ticks over which tick statistics are
+// averaged.
+const TickSamples = 100
+
+// tickStats tracks the rate and duration of recent world ticks.
+type tickStats struct {
+	mu        sync.Mutex
+	last      time.Time
+	intervals [TickSamples]time.Duration
+	durations [TickSamples]time.Duration
+	count     int
+	next      int
+}
+
+// record adds a tick that started at the given time and took the given
+// duration to process.
+func (ts *tickStats) record(start time.Time, duration time.Duration) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if !ts.last.IsZero() {
+		ts.intervals[ts.next] = start.Sub(ts.last)
+		ts.durations[ts.next] = duration
+		ts.next = (ts.next + 1) % TickSamples
+
+		if ts.count < TickSamples {
+			ts.count++
+		}
+	}
+
+	ts.last = start
+}
+
+// TPS returns the average number of ticks per second, which is at most 20.
+func (ts *tickStats) TPS() float64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.count == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, interval := range ts.intervals[:ts.count] {
+		total += interval
+	}
+
+	tps := float64(ts.count) / total.Seconds()
+	if max := float64(time.Second / TickDuration); tps > max {
+		tps = max
+	}
+
+	return tps
+}
+
+// MSPT returns the average number of milliseconds spent processing each tick.
+func (ts *tickStats) MSPT() float64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.count == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, duration := range ts.durations[:ts.count] {
+		total += duration
+	}
+
+	return float64(total) / float64(ts.count) / float64(time.Millisecond)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/runner"
+)
+
+// ReloadDelay is how long the file watcher waits after the last change to a
+// watched file before reloading it, so that a file that is written in several
+// steps is only reloaded once.
+const ReloadDelay = 500 * time.Millisecond
+
+var watcherLogger = logs.Component("watcher")
+
+// FileWatcher watches the server's configuration files, and reloads them when
+// they change.
+type FileWatcher struct {
+	*runner.Runner
+	watcher *fsnotify.Watcher
+	files   map[string]bool
+	reload  func(files ...string) string
+}
+
+// NewFileWatcher returns a new FileWatcher that calls reload with the names of
+// the files that changed. Files are watched through their directory, so that
+// files replaced by editors or atomic writes are still watched.
+func NewFileWatcher(ctx context.Context, reload func(files ...string) string, files ...string) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("Could not watch configuration files: %v", err)
+	}
+
+	if err := watcher.Add("."); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("Could not watch configuration files: %v", err)
+	}
+
+	fw := &FileWatcher{
+		watcher: watcher,
+		files:   make(map[string]bool, len(files)),
+		reload:  reload,
+	}
+
+	for _, file := range files {
+		fw.files[file] = true
+	}
+
+	fw.Runner = runner.NewRunner(ctx, fw)
+	return fw, nil
+}
+
+// Name returns the name of the file watcher.
+func (fw *FileWatcher) Name() string {
+	return "File watcher"
+}
+
+// Setup logs the watched files, which are watched from the time the watcher
+// is created.
+func (fw *FileWatcher) Setup() {
+	watcherLogger.WithField("files", strings.Join(fw.sortedFiles(), ",")).Debug("Watching configuration files")
+}
+
+// Run reloads files once they have stopped changing, until the watcher is
+// stopped.
+func (fw *FileWatcher) Run() {
+	changed := make(map[string]bool)
+	timer := time.NewTimer(ReloadDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-fw.Done():
+			timer.Stop()
+			return
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+
+			name := filepath.Base(event.Name)
+			if !fw.files[name] || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			changed[name] = true
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			timer.Reset(ReloadDelay)
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			watcherLogger.WithError(err).Error("Error watching configuration files")
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for name := range changed {
+				files = append(files, name)
+			}
+
+			sort.Strings(files)
+			changed = make(map[string]bool)
+
+			entry := watcherLogger.WithField("files", strings.Join(files, ","))
+			entry.Info("Reloading changed configuration files")
+
+			for _, line := range strings.Split(fw.reload(files...), "\n") {
+				if line != "" {
+					entry.Info(line)
+				}
+			}
+		}
+	}
+}
+
+// Cleanup stops watching the files.
+func (fw *FileWatcher) Cleanup() {
+	if err := fw.watcher.Close(); err != nil {
+		watcherLogger.WithError(err).Error("Error closing file watcher")
+	}
+}
+
+func (fw *FileWatcher) sortedFiles() []string {
+	files := make([]string, 0, len(fw.files))
+	for file := range fw.files {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+	return files
+}
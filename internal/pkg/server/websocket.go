@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/rcon"
+	"github.com/jbhannah/gophermine/pkg/runner"
+)
+
+// WebSocketServer accepts RCON connections over WebSocket, for browser-based
+// administration tools that cannot open raw TCP connections.
+type WebSocketServer struct {
+	*runner.Runner
+	http     *http.Server
+	listener net.Listener
+	upgrader *websocket.Upgrader
+	tls      bool
+	wg       *sync.WaitGroup
+}
+
+// NewWebSocketServer returns a new WebSocketServer listening at the address.
+// Connections are served over TLS with the same configuration as the RCON
+// server, if any. Browsers may only connect from the same origin as the
+// server, or from the origins in the rcon.websocket.allowed-origins property.
+func NewWebSocketServer(ctx context.Context, addr string) (*WebSocketServer, error) {
+	srv := &WebSocketServer{
+		upgrader: &websocket.Upgrader{},
+		wg:       &sync.WaitGroup{},
+	}
+
+	props := mc.Properties()
+	srv.http = &http.Server{Handler: srv}
+
+	if props.RCONTLS() {
+		config, err := rcon.ServerTLSConfig(props.RCON.TLS.CertFile, props.RCON.TLS.KeyFile, props.RCON.TLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		srv.http.TLSConfig = config
+		srv.tls = true
+	}
+
+	if origins := props.RCON.WebSocket.AllowedOrigins; origins != "" {
+		srv.upgrader.CheckOrigin = allowedOrigins(strings.Split(origins, ","))
+	}
+
+	listen, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Could not listen on %s for %s: %v", addr, srv.Name(), err)
+	}
+
+	srv.listener = listen
+	srv.Runner = runner.NewRunner(ctx, srv)
+
+	return srv, nil
+}
+
+// Name returns the name of the WebSocket RCON server.
+func (srv *WebSocketServer) Name() string {
+	return "RCON WebSocket"
+}
+
+// Setup starts serving HTTP requests.
+func (srv *WebSocketServer) Setup() {
+	defer rconLogger.WithField(logs.AddrField, srv.listener.Addr().String()).WithField("tls", srv.tls).Info("Listening for WebSocket connections")
+
+	go func() {
+		var err error
+		if srv.tls {
+			err = srv.http.ServeTLS(srv.listener, "", "")
+		} else {
+			err = srv.http.Serve(srv.listener)
+		}
+
+		if err != http.ErrServerClosed {
+			rconLogger.WithError(err).Error("Error serving WebSocket connections")
+		}
+	}()
+}
+
+// Run blocks until the server is stopped.
+func (srv *WebSocketServer) Run() {
+	<-srv.Done()
+}
+
+// Cleanup stops serving HTTP requests, and waits for open connections to
+// close.
+func (srv *WebSocketServer) Cleanup() {
+	defer rconLogger.WithField(logs.AddrField, srv.listener.Addr().String()).Debug("Stopped listening for WebSocket connections")
+
+	if err := srv.http.Shutdown(context.Background()); err != nil {
+		rconLogger.WithError(err).Error("Error stopping WebSocket server")
+	}
+
+	srv.wg.Wait()
+}
+
+// ServeHTTP upgrades an HTTP request to a WebSocket RCON connection, and
+// handles it until it is closed.
+func (srv *WebSocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entry := rconLogger.WithField(logs.RemoteAddrField, r.RemoteAddr)
+
+	ws, err := srv.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		entry.WithError(err).Warn("Could not accept WebSocket connection")
+		return
+	}
+
+	srv.wg.Add(1)
+	defer srv.wg.Done()
+
+	entry.Info("Accepted WebSocket connection")
+	defer entry.Debug("Closed WebSocket connection")
+
+	var certs []*x509.Certificate
+	if r.TLS != nil {
+		certs = r.TLS.PeerCertificates
+	}
+
+	conn, err := rcon.NewWebSocketConn(srv.Context, ws, certs)
+	if err != nil {
+		entry.WithError(err).Error("Could not initialize RCON console")
+		ws.Close()
+		return
+	}
+
+	<-conn.Start()
+	<-conn.Stopped()
+}
+
+// allowedOrigins returns a function that checks that the Origin header of a
+// WebSocket request, if any, is one of the origins, or that "*" is one of the
+// origins.
+func allowedOrigins(origins []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		for _, allowed := range origins {
+			allowed = strings.TrimSpace(allowed)
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
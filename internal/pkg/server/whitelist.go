@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+const whitelistUsage = "Usage: whitelist <on|off|add|remove|list|reload> [player]"
+
+func (server *Server) whitelistCommand(cmd *mc.Command) (string, error) {
+	if len(cmd.Args) < 1 {
+		return whitelistUsage, nil
+	}
+
+	wl := mc.Whitelist()
+
+	switch cmd.Args[0] {
+	case "on":
+		if mc.Properties().WhiteList {
+			return "Whitelist is already turned on", nil
+		}
+
+		if err := mc.Properties().SetWhiteList(true); err != nil {
+			return "", err
+		}
+
+		server.mc.EnforceWhitelist()
+		return "Whitelist is now turned on", nil
+	case "off":
+		if !mc.Properties().WhiteList {
+			return "Whitelist is already turned off", nil
+		}
+
+		if err := mc.Properties().SetWhiteList(false); err != nil {
+			return "", err
+		}
+
+		return "Whitelist is now turned off", nil
+	case "add", "remove":
+		if len(cmd.Args) != 2 {
+			return whitelistUsage, nil
+		}
+
+		players, err := server.selectProfiles(cmd, cmd.Args[1])
+		if err != nil {
+			return err.Error(), nil
+		}
+
+		if cmd.Args[0] == "add" {
+			return server.whitelistAdd(players)
+		}
+
+		return server.whitelistRemove(players)
+	case "list":
+		names := wl.Names()
+		if len(names) == 0 {
+			return "There are no whitelisted players", nil
+		}
+
+		return fmt.Sprintf("There are %d whitelisted players: %s", len(names), strings.Join(names, ", ")), nil
+	case "reload":
+		if err := wl.Load(); err != nil {
+			return "", err
+		}
+
+		server.mc.EnforceWhitelist()
+		return "Reloaded the whitelist", nil
+	}
+
+	return whitelistUsage, nil
+}
+
+func (server *Server) whitelistAdd(players []*mc.Player) (string, error) {
+	added := make([]string, 0, len(players))
+
+	for _, player := range players {
+		if mc.Whitelist().Add(player) {
+			added = append(added, fmt.Sprintf("Added %s to the whitelist", player))
+		}
+	}
+
+	if len(added) == 0 {
+		return "Player is already whitelisted", nil
+	}
+
+	if err := mc.Whitelist().Save(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(added, "\n"), nil
+}
+
+func (server *Server) whitelistRemove(players []*mc.Player) (string, error) {
+	removed := make([]string, 0, len(players))
+
+	for _, player := range players {
+		if mc.Whitelist().Remove(player) {
+			removed = append(removed, fmt.Sprintf("Removed %s from the whitelist", player))
+		}
+	}
+
+	if len(removed) == 0 {
+		return "Player is not whitelisted", nil
+	}
+
+	if err := mc.Whitelist().Save(); err != nil {
+		return "", err
+	}
+
+	server.mc.EnforceWhitelist()
+	return strings.Join(removed, "\n"), nil
+}
@@ -3,7 +3,7 @@ package utils
 import "io"
 
 // LineWriter is an io.Writer that appends a newline character to the argument
-// to each call to Write.
+// to each call to Write. Empty writes are discarded.
 type LineWriter struct {
 	io.Writer
 }
@@ -11,6 +11,10 @@ type LineWriter struct {
 // Write writes the given bytes to the underlying writer and appends a newline
 // character to the end.
 func (sc *LineWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
 	_, err := sc.writeLine(p)
 	return len(p), err
 }
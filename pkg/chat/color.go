@@ -0,0 +1,75 @@
+package chat
+
+// FormattingCode is the prefix of legacy formatting codes.
+const FormattingCode = '§'
+
+// Color is the name of a text color.
+type Color string
+
+// Text colors.
+const (
+	Black       Color = "black"
+	DarkBlue    Color = "dark_blue"
+	DarkGreen   Color = "dark_green"
+	DarkAqua    Color = "dark_aqua"
+	DarkRed     Color = "dark_red"
+	DarkPurple  Color = "dark_purple"
+	Gold        Color = "gold"
+	Gray        Color = "gray"
+	DarkGray    Color = "dark_gray"
+	Blue        Color = "blue"
+	Green       Color = "green"
+	Aqua        Color = "aqua"
+	Red         Color = "red"
+	LightPurple Color = "light_purple"
+	Yellow      Color = "yellow"
+	White       Color = "white"
+	Reset       Color = "reset"
+)
+
+var colorCodes = map[Color]rune{
+	Black:       '0',
+	DarkBlue:    '1',
+	DarkGreen:   '2',
+	DarkAqua:    '3',
+	DarkRed:     '4',
+	DarkPurple:  '5',
+	Gold:        '6',
+	Gray:        '7',
+	DarkGray:    '8',
+	Blue:        '9',
+	Green:       'a',
+	Aqua:        'b',
+	Red:         'c',
+	LightPurple: 'd',
+	Yellow:      'e',
+	White:       'f',
+	Reset:       'r',
+}
+
+// Legacy style codes.
+const (
+	ObfuscatedCode    = 'k'
+	BoldCode          = 'l'
+	StrikethroughCode = 'm'
+	UnderlinedCode    = 'n'
+	ItalicCode        = 'o'
+	ResetCode         = 'r'
+)
+
+// Code returns the legacy formatting code of the color, or 0 if the color is
+// not valid.
+func (color Color) Code() rune {
+	return colorCodes[color]
+}
+
+// ColorForCode returns the color with the given legacy formatting code.
+func ColorForCode(code rune) (Color, bool) {
+	for color, c := range colorCodes {
+		if c == code {
+			return color, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,408 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ClickAction is the action performed when a component is clicked.
+type ClickAction string
+
+// Click actions.
+const (
+	OpenURL        ClickAction = "open_url"
+	RunCommand     ClickAction = "run_command"
+	SuggestCommand ClickAction = "suggest_command"
+	ChangePage     ClickAction = "change_page"
+)
+
+// HoverAction is the action performed when a component is hovered over.
+type HoverAction string
+
+// Hover actions.
+const (
+	ShowText   HoverAction = "show_text"
+	ShowItem   HoverAction = "show_item"
+	ShowEntity HoverAction = "show_entity"
+)
+
+// ClickEvent is performed when a component is clicked.
+type ClickEvent struct {
+	Action ClickAction `json:"action"`
+	Value  string      `json:"value"`
+}
+
+// HoverEvent is performed when a component is hovered over.
+type HoverEvent struct {
+	Action HoverAction `json:"action"`
+	Value  *Component  `json:"value"`
+}
+
+// Score is the content of a score component.
+type Score struct {
+	Name      string `json:"name"`
+	Objective string `json:"objective"`
+	Value     string `json:"value,omitempty"`
+}
+
+// Component is a JSON text component.
+type Component struct {
+	// Exactly one of the content fields is used, in this order of precedence:
+	// Translate, Score, Selector, Keybind and Text.
+	Text      string       `json:"text,omitempty"`
+	Translate string       `json:"translate,omitempty"`
+	With      []*Component `json:"with,omitempty"`
+	Score     *Score       `json:"score,omitempty"`
+	Selector  string       `json:"selector,omitempty"`
+	Keybind   string       `json:"keybind,omitempty"`
+
+	Color         Color `json:"color,omitempty"`
+	Bold          *bool `json:"bold,omitempty"`
+	Italic        *bool `json:"italic,omitempty"`
+	Underlined    *bool `json:"underlined,omitempty"`
+	Strikethrough *bool `json:"strikethrough,omitempty"`
+	Obfuscated    *bool `json:"obfuscated,omitempty"`
+
+	Insertion  string      `json:"insertion,omitempty"`
+	ClickEvent *ClickEvent `json:"clickEvent,omitempty"`
+	HoverEvent *HoverEvent `json:"hoverEvent,omitempty"`
+
+	Extra []*Component `json:"extra,omitempty"`
+}
+
+// Text returns a component with the given text.
+func Text(text string) *Component {
+	return &Component{Text: text}
+}
+
+// Textf returns a component with formatted text.
+func Textf(format string, args ...interface{}) *Component {
+	return Text(fmt.Sprintf(format, args...))
+}
+
+// Translate returns a component that is translated by the client, with the
+// given components substituted into the translation.
+func Translate(key string, with ...*Component) *Component {
+	return &Component{Translate: key, With: with}
+}
+
+// ScoreOf returns a component showing the score of the named entity in the
+// objective.
+func ScoreOf(name, objective string) *Component {
+	return &Component{Score: &Score{Name: name, Objective: objective}}
+}
+
+// Selector returns a component showing the names of the entities selected by
+// the target selector.
+func Selector(selector string) *Component {
+	return &Component{Selector: selector}
+}
+
+// Keybind returns a component showing the key bound to the given control.
+func Keybind(key string) *Component {
+	return &Component{Keybind: key}
+}
+
+// SetColor sets the color of the component.
+func (c *Component) SetColor(color Color) *Component {
+	c.Color = color
+	return c
+}
+
+// SetBold sets whether the component is bold.
+func (c *Component) SetBold(v bool) *Component {
+	c.Bold = &v
+	return c
+}
+
+// SetItalic sets whether the component is italic.
+func (c *Component) SetItalic(v bool) *Component {
+	c.Italic = &v
+	return c
+}
+
+// SetUnderlined sets whether the component is underlined.
+func (c *Component) SetUnderlined(v bool) *Component {
+	c.Underlined = &v
+	return c
+}
+
+// SetStrikethrough sets whether the component is struck through.
+func (c *Component) SetStrikethrough(v bool) *Component {
+	c.Strikethrough = &v
+	return c
+}
+
+// SetObfuscated sets whether the component is obfuscated.
+func (c *Component) SetObfuscated(v bool) *Component {
+	c.Obfuscated = &v
+	return c
+}
+
+// SetInsertion sets the text inserted into chat when the component is
+// shift-clicked.
+func (c *Component) SetInsertion(text string) *Component {
+	c.Insertion = text
+	return c
+}
+
+// OnClick sets the action performed when the component is clicked.
+func (c *Component) OnClick(action ClickAction, value string) *Component {
+	c.ClickEvent = &ClickEvent{Action: action, Value: value}
+	return c
+}
+
+// OnHover sets the action performed when the component is hovered over.
+func (c *Component) OnHover(action HoverAction, value *Component) *Component {
+	c.HoverEvent = &HoverEvent{Action: action, Value: value}
+	return c
+}
+
+// Append adds children to the component, which inherit its style.
+func (c *Component) Append(children ...*Component) *Component {
+	c.Extra = append(c.Extra, children...)
+	return c
+}
+
+// Parse parses a JSON text component, which may be a string, an object, or a
+// list in which the first element is the parent of the others.
+func Parse(data []byte) (*Component, error) {
+	c := &Component{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// UnmarshalJSON decodes any of the JSON forms of a text component.
+func (c *Component) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return fmt.Errorf("Empty text component")
+	}
+
+	if bytes.Equal(data, []byte("null")) {
+		return fmt.Errorf("Text component cannot be null")
+	}
+
+	switch data[0] {
+	case '"':
+		*c = Component{}
+		return json.Unmarshal(data, &c.Text)
+	case '[':
+		var list []*Component
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return fmt.Errorf("Empty text component list")
+		}
+
+		if err := checkNull("Text component list", list); err != nil {
+			return err
+		}
+
+		*c = *list[0]
+		c.Extra = append(c.Extra, list[1:]...)
+
+		return nil
+	case '{':
+		// Decode into a type without this method to avoid recursion.
+		type component Component
+		var v component
+
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+
+		if err := checkNull("The 'with' list of a text component", v.With); err != nil {
+			return err
+		}
+
+		if err := checkNull("The 'extra' list of a text component", v.Extra); err != nil {
+			return err
+		}
+
+		*c = Component(v)
+		return nil
+	}
+
+	// Numbers and booleans are treated as text.
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*c = Component{Text: fmt.Sprint(v)}
+	return nil
+}
+
+// checkNull returns an error if any of the components decoded from the
+// described list was null, which json.Unmarshal decodes as a nil component
+// without calling UnmarshalJSON.
+func checkNull(desc string, list []*Component) error {
+	for i, c := range list {
+		if c == nil {
+			return fmt.Errorf("%s cannot contain null (element %d)", desc, i)
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the component as a JSON object. Components without any
+// other content always include a text field, as clients require one.
+func (c *Component) MarshalJSON() ([]byte, error) {
+	// Encode a type without this method to avoid recursion.
+	type component Component
+
+	var text *string
+	if c.Text != "" || (c.Translate == "" && c.Score == nil && c.Selector == "" && c.Keybind == "") {
+		text = &c.Text
+	}
+
+	return json.Marshal(struct {
+		Text *string `json:"text,omitempty"`
+		*component
+	}{text, (*component)(c)})
+}
+
+// JSON returns the JSON form of the component.
+func (c *Component) JSON() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf(`{"text":%q}`, c.PlainText())
+	}
+
+	return string(data)
+}
+
+// String returns the JSON form of the component.
+func (c *Component) String() string {
+	return c.JSON()
+}
+
+// PlainText returns the text of the component and its children, with
+// translations applied and without formatting.
+func (c *Component) PlainText() string {
+	buf := &strings.Builder{}
+	c.writeText(buf, nil)
+
+	return buf.String()
+}
+
+// Legacy returns the text of the component and its children, with
+// translations applied and formatting represented by legacy codes.
+func (c *Component) Legacy() string {
+	buf := &strings.Builder{}
+	c.writeText(buf, &style{})
+
+	return buf.String()
+}
+
+// writeText writes the content of the component and its children to the
+// buffer. If parent is not nil, legacy formatting codes are written as well.
+func (c *Component) writeText(buf *strings.Builder, parent *style) {
+	var st *style
+	if parent != nil {
+		merged := parent.merge(c)
+		st = &merged
+		buf.WriteString(st.codes())
+	}
+
+	switch {
+	case c.Translate != "":
+		args := make([]interface{}, len(c.With))
+
+		for i, with := range c.With {
+			arg := &strings.Builder{}
+			if with != nil {
+				with.writeText(arg, st)
+			}
+
+			if st != nil {
+				arg.WriteString(st.codes())
+			}
+
+			args[i] = arg.String()
+		}
+
+		buf.WriteString(translate(c.Translate, args))
+	case c.Score != nil:
+		buf.WriteString(c.Score.Value)
+	case c.Selector != "":
+		buf.WriteString(c.Selector)
+	case c.Keybind != "":
+		buf.WriteString(c.Keybind)
+	default:
+		buf.WriteString(c.Text)
+	}
+
+	for _, extra := range c.Extra {
+		if extra != nil {
+			extra.writeText(buf, st)
+		}
+	}
+}
+
+// style is the formatting of a component, including that inherited from its
+// parents.
+type style struct {
+	color                                               Color
+	bold, italic, underlined, strikethrough, obfuscated bool
+}
+
+func (st style) merge(c *Component) style {
+	if c.Color == Reset {
+		st.color = ""
+	} else if c.Color.Code() != 0 {
+		st.color = c.Color
+	}
+
+	for _, flag := range []struct {
+		set *bool
+		dst *bool
+	}{
+		{c.Bold, &st.bold},
+		{c.Italic, &st.italic},
+		{c.Underlined, &st.underlined},
+		{c.Strikethrough, &st.strikethrough},
+		{c.Obfuscated, &st.obfuscated},
+	} {
+		if flag.set != nil {
+			*flag.dst = *flag.set
+		}
+	}
+
+	return st
+}
+
+// codes returns the legacy formatting codes that reset the formatting to the
+// style.
+func (st style) codes() string {
+	codes := []rune{FormattingCode, ResetCode}
+	if st.color != "" {
+		codes[1] = st.color.Code()
+	}
+
+	for _, flag := range []struct {
+		set  bool
+		code rune
+	}{
+		{st.obfuscated, ObfuscatedCode},
+		{st.bold, BoldCode},
+		{st.strikethrough, StrikethroughCode},
+		{st.underlined, UnderlinedCode},
+		{st.italic, ItalicCode},
+	} {
+		if flag.set {
+			codes = append(codes, FormattingCode, flag.code)
+		}
+	}
+
+	return string(codes)
+}
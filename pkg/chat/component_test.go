@@ -0,0 +1,88 @@
+package chat
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		plain string
+	}{
+		{`"hello"`, "hello"},
+		{`{"text":"hello"}`, "hello"},
+		{`["a",{"text":"b"},"c"]`, "abc"},
+		{`{"text":"a","extra":["b",{"text":"c","extra":["d"]}]}`, "abcd"},
+		{`{"translate":"chat.type.text","with":["Steve","hi"]}`, "<Steve> hi"},
+		{`{"translate":"chat.type.text","with":["Steve"]}`, "<Steve> "},
+		{`{"translate":"chat.type.text","with":["Steve","hi","extra"]}`, "<Steve> hi"},
+		{`{"translate":"unknown.key","with":["a"]}`, "unknown.key a"},
+		{`42`, "42"},
+		{`true`, "true"},
+	}
+
+	for _, test := range tests {
+		c, err := Parse([]byte(test.input))
+		if err != nil {
+			t.Errorf("Parse(%s) returned error: %v", test.input, err)
+			continue
+		}
+
+		if plain := c.PlainText(); plain != test.plain {
+			t.Errorf("Parse(%s).PlainText() = %q, expected %q", test.input, plain, test.plain)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{
+		``,
+		`null`,
+		`[]`,
+		`[null]`,
+		`["a",null]`,
+		`{"text":"a","extra":[null]}`,
+		`{"text":"a","extra":["b",{"text":"c","extra":[null]}]}`,
+		`{"translate":"chat.type.text","with":[null]}`,
+		`{"translate":"chat.type.text","with":["a",[null]]}`,
+		`{"text":`,
+		`[`,
+	}
+
+	for _, input := range inputs {
+		if c, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%s) = %s, expected an error", input, c)
+		}
+	}
+}
+
+func TestWriteTextNilChildren(t *testing.T) {
+	c := Translate("chat.type.text", nil, Text("hi")).Append(nil, Text("!"))
+
+	if plain := c.PlainText(); plain != "<> hi!" {
+		t.Errorf("PlainText() = %q, expected %q", plain, "<> hi!")
+	}
+
+	if legacy := c.Legacy(); legacy == "" {
+		t.Error("Legacy() returned empty text")
+	}
+
+	if json := c.JSON(); json == "" {
+		t.Error("JSON() returned empty text")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	c := Text("a").SetColor(Red).SetBold(true).Append(Translate("chat.type.text", Text("Steve"), Text("hi")))
+
+	parsed, err := Parse([]byte(c.JSON()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.JSON() != c.JSON() {
+		t.Errorf("round trip produced %s, expected %s", parsed.JSON(), c.JSON())
+	}
+
+	if parsed.Legacy() != c.Legacy() {
+		t.Errorf("round trip produced legacy text %q, expected %q", parsed.Legacy(), c.Legacy())
+	}
+}
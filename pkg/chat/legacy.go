@@ -0,0 +1,111 @@
+package chat
+
+import "strings"
+
+// ParseLegacy converts text with legacy formatting codes to a component, with
+// a child for each run of differently formatted text.
+func ParseLegacy(text string) *Component {
+	root := Text("")
+	current := &strings.Builder{}
+	st := style{}
+
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+
+		root.Append(st.component(current.String()))
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != FormattingCode || i+1 >= len(runes) {
+			current.WriteRune(runes[i])
+			continue
+		}
+
+		flush()
+
+		i++
+		code := runes[i]
+		if code >= 'A' && code <= 'Z' {
+			code += 'a' - 'A'
+		}
+
+		switch code {
+		case ObfuscatedCode:
+			st.obfuscated = true
+		case BoldCode:
+			st.bold = true
+		case StrikethroughCode:
+			st.strikethrough = true
+		case UnderlinedCode:
+			st.underlined = true
+		case ItalicCode:
+			st.italic = true
+		case ResetCode:
+			st = style{}
+		default:
+			// Colors also reset all styles.
+			if color, ok := ColorForCode(code); ok {
+				st = style{color: color}
+			}
+		}
+	}
+
+	flush()
+
+	if len(root.Extra) == 1 {
+		return root.Extra[0]
+	}
+
+	return root
+}
+
+// StripLegacy removes legacy formatting codes from text.
+func StripLegacy(text string) string {
+	if !strings.ContainsRune(text, FormattingCode) {
+		return text
+	}
+
+	buf := &strings.Builder{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == FormattingCode && i+1 < len(runes) {
+			i++
+			continue
+		}
+
+		buf.WriteRune(runes[i])
+	}
+
+	return buf.String()
+}
+
+// component returns a text component with the style.
+func (st style) component(text string) *Component {
+	c := Text(text)
+
+	if st.color != "" {
+		c.SetColor(st.color)
+	}
+
+	for _, flag := range []struct {
+		set   bool
+		apply func(bool) *Component
+	}{
+		{st.bold, c.SetBold},
+		{st.italic, c.SetItalic},
+		{st.underlined, c.SetUnderlined},
+		{st.strikethrough, c.SetStrikethrough},
+		{st.obfuscated, c.SetObfuscated},
+	} {
+		if flag.set {
+			flag.apply(true)
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Translation keys used by the server.
+const (
+	AnnouncementKey   = "chat.type.announcement"
+	ChatKey           = "chat.type.text"
+	EmoteKey          = "chat.type.emote"
+	BannedKey         = "multiplayer.disconnect.banned.reason"
+	BannedIPKey       = "multiplayer.disconnect.banned_ip.reason"
+	BanExpirationKey  = "multiplayer.disconnect.banned.expiration"
+	KickedKey         = "multiplayer.disconnect.kicked"
+	NotWhitelistedKey = "multiplayer.disconnect.not_whitelisted"
+	ServerShutdownKey = "multiplayer.disconnect.server_shutdown"
+	PlayerJoinedKey   = "multiplayer.player.joined"
+	PlayerLeftKey     = "multiplayer.player.left"
+)
+
+// translations are the English formats of the translation keys used by the
+// server, for rendering components outside of the client.
+var translations = map[string]string{
+	AnnouncementKey:   "[%s] %s",
+	ChatKey:           "<%s> %s",
+	EmoteKey:          "* %s %s",
+	BannedKey:         "You are banned from this server.\nReason: %s",
+	BannedIPKey:       "Your IP address is banned from this server.\nReason: %s",
+	BanExpirationKey:  "\nYour ban will be removed on %s",
+	KickedKey:         "Kicked by an operator",
+	NotWhitelistedKey: "You are not white-listed on this server!",
+	ServerShutdownKey: "Server closed",
+	PlayerJoinedKey:   "%s joined the game",
+	PlayerLeftKey:     "%s left the game",
+}
+
+// translate applies the English format of the translation key to the args.
+// Unknown keys are rendered as the key followed by the args.
+func translate(key string, args []interface{}) string {
+	format, ok := translations[key]
+	if !ok {
+		strs := make([]string, 0, len(args)+1)
+		strs = append(strs, key)
+
+		for _, arg := range args {
+			strs = append(strs, fmt.Sprint(arg))
+		}
+
+		return strings.Join(strs, " ")
+	}
+
+	// Components may have more or fewer with arguments than the format has
+	// verbs; missing arguments are rendered as empty and extras are ignored.
+	verbs := strings.Count(strings.Replace(format, "%%", "", -1), "%")
+	padded := make([]interface{}, verbs)
+	for i := range padded {
+		if i < len(args) {
+			padded[i] = args[i]
+		} else {
+			padded[i] = ""
+		}
+	}
+
+	return fmt.Sprintf(format, padded...)
+}
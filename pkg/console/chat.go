@@ -0,0 +1,118 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/chat"
+	log "github.com/sirupsen/logrus"
+)
+
+// ansiReset resets all colors and styles.
+const ansiReset = "\033[0m"
+
+// ansiColors maps Minecraft color codes to ANSI color parameters.
+var ansiColors = map[rune]string{
+	'0': "30",
+	'1': "34",
+	'2': "32",
+	'3': "36",
+	'4': "31",
+	'5': "35",
+	'6': "33",
+	'7': "37",
+	'8': "90",
+	'9': "94",
+	'a': "92",
+	'b': "96",
+	'c': "91",
+	'd': "95",
+	'e': "93",
+	'f': "97",
+}
+
+// ansiStyles maps Minecraft style codes to ANSI style parameters.
+var ansiStyles = map[rune]string{
+	chat.ObfuscatedCode:    "5",
+	chat.BoldCode:          "1",
+	chat.StrikethroughCode: "9",
+	chat.UnderlinedCode:    "4",
+	chat.ItalicCode:        "3",
+}
+
+// ChatFormatter renders Minecraft formatting codes and JSON text components in
+// log messages as ANSI escape sequences, or strips them if ANSI is false.
+type ChatFormatter struct {
+	log.Formatter
+	ANSI bool
+}
+
+// Format renders the message of the entry before formatting it with the
+// underlying formatter.
+func (cf *ChatFormatter) Format(entry *log.Entry) ([]byte, error) {
+	e := *entry
+	e.Message = RenderChat(e.Message, cf.ANSI)
+
+	return cf.Formatter.Format(&e)
+}
+
+// RenderChat converts a message containing legacy formatting codes, or
+// consisting of a JSON text component, to text with ANSI escape sequences.
+// If ansi is false, the formatting is removed instead. Messages that look like
+// JSON but are not a text component with any text, such as other JSON logged
+// as is, are kept as they are.
+func RenderChat(message string, ansi bool) string {
+	if trimmed := strings.TrimSpace(message); isComponent(trimmed) {
+		if c, err := chat.Parse([]byte(trimmed)); err == nil && c.PlainText() != "" {
+			message = c.Legacy()
+		}
+	}
+
+	if !ansi {
+		return chat.StripLegacy(message)
+	}
+
+	if !strings.ContainsRune(message, chat.FormattingCode) {
+		return message
+	}
+
+	buf := &strings.Builder{}
+	formatted := false
+	runes := []rune(message)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != chat.FormattingCode || i+1 >= len(runes) {
+			buf.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		code := runes[i]
+		if code >= 'A' && code <= 'Z' {
+			code += 'a' - 'A'
+		}
+
+		if color, ok := ansiColors[code]; ok {
+			fmt.Fprintf(buf, "\033[0;%sm", color)
+			formatted = true
+		} else if style, ok := ansiStyles[code]; ok {
+			fmt.Fprintf(buf, "\033[%sm", style)
+			formatted = true
+		} else if code == chat.ResetCode {
+			buf.WriteString(ansiReset)
+			formatted = false
+		}
+	}
+
+	if formatted {
+		buf.WriteString(ansiReset)
+	}
+
+	return buf.String()
+}
+
+// isComponent reports whether a message looks like a JSON text component
+// object or list, rather than text that happens to begin with a bracket.
+func isComponent(message string) bool {
+	return strings.HasPrefix(message, "{") || strings.HasPrefix(message, "[{") || strings.HasPrefix(message, "[\"")
+}
@@ -0,0 +1,35 @@
+package console
+
+import "testing"
+
+func TestRenderChat(t *testing.T) {
+	tests := []struct {
+		message string
+		plain   string
+		ansi    string
+	}{
+		{"Started Gophermine", "Started Gophermine", "Started Gophermine"},
+		{"§aGreen §lbold§r plain", "Green bold plain", "\033[0;92mGreen \033[1mbold\033[0m plain"},
+		{"§Ccaps", "caps", "\033[0;91mcaps\033[0m"},
+		{"trailing §", "trailing §", "trailing §"},
+		{`{"text":"hi","color":"red"}`, "hi", "\033[0;91mhi\033[0m"},
+		{` ["a",{"text":"b"}] `, "ab", "\033[0ma\033[0mb"},
+		{`{"foo":1}`, `{"foo":1}`, `{"foo":1}`},
+		{`{"text":""}`, `{"text":""}`, `{"text":""}`},
+		{`{"text":`, `{"text":`, `{"text":`},
+		{`[null]`, `[null]`, `[null]`},
+		{`["a",null]`, `["a",null]`, `["a",null]`},
+		{`[1, 2]`, `[1, 2]`, `[1, 2]`},
+		{"{braces} in text", "{braces} in text", "{braces} in text"},
+	}
+
+	for _, test := range tests {
+		if plain := RenderChat(test.message, false); plain != test.plain {
+			t.Errorf("RenderChat(%q, false) = %q, expected %q", test.message, plain, test.plain)
+		}
+
+		if ansi := RenderChat(test.message, true); ansi != test.ansi {
+			t.Errorf("RenderChat(%q, true) = %q, expected %q", test.message, ansi, test.ansi)
+		}
+	}
+}
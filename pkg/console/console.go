@@ -4,14 +4,20 @@ import (
 	"bufio"
 	"context"
 	"io"
-	"strings"
+	"net"
 
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/mc"
 	"github.com/jbhannah/gophermine/pkg/runner"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// LineReader reads lines of command input.
+type LineReader interface {
+	ReadLine() (string, error)
+}
+
 // Console is a command entry console for a running server. If the server is
 // started with an attached TTY, one is instantiated to accept directly entered
 // commands. If RCON is enabled for the server, one is instantiated for each
@@ -19,19 +25,45 @@ import (
 type Console struct {
 	io.Writer
 	*runner.Runner
-	*bufio.Scanner
+	LineReader
 	Commands   chan *mc.Command
+	requests   RequestReader
 	ctxStarted chan struct{}
+	log        *log.Entry
 	name       string
+	stopOnEOF  bool
 }
 
 // NewConsole creates a new console.
 func NewConsole(ctx context.Context, name string, reader io.Reader, writer io.Writer) (*Console, error) {
+	return newConsole(ctx, name, &scanner{bufio.NewScanner(reader)}, writer)
+}
+
+// NewStdinConsole creates a console that reads newline-delimited commands from
+// the reader. If stopOnEOF is set, a stop command is sent when the end of the
+// input is reached.
+func NewStdinConsole(ctx context.Context, name string, reader io.Reader, writer io.Writer, stopOnEOF bool) (*Console, error) {
+	console, err := NewConsole(ctx, name, reader, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	console.stopOnEOF = stopOnEOF
+	return console, nil
+}
+
+// NewLineConsole creates a console that reads commands from the line reader.
+func NewLineConsole(ctx context.Context, name string, reader LineReader, writer io.Writer) (*Console, error) {
+	return newConsole(ctx, name, reader, writer)
+}
+
+func newConsole(ctx context.Context, name string, reader LineReader, writer io.Writer) (*Console, error) {
 	console := &Console{
 		Writer:     writer,
-		Scanner:    bufio.NewScanner(reader),
+		LineReader: reader,
 		Commands:   ctx.Value(mc.ServerCommands).(chan *mc.Command),
 		ctxStarted: ctx.Value(runner.RunnableStarted).(chan struct{}),
+		log:        logs.Component("console").WithField(logs.NameField, name),
 		name:       name,
 	}
 
@@ -44,6 +76,37 @@ func (console *Console) Name() string {
 	return console.name
 }
 
+// RemoteAddr returns the network address of the client that is sending
+// commands to the console, or nil if the console is local.
+func (console *Console) RemoteAddr() net.Addr {
+	if ra, ok := console.Writer.(interface{ RemoteAddr() net.Addr }); ok {
+		return ra.RemoteAddr()
+	}
+
+	return nil
+}
+
+// PermissionLevel returns the permission level of the client that is sending
+// commands to the console, or mc.MaxPermissionLevel if the console is local.
+func (console *Console) PermissionLevel() int {
+	if po, ok := console.Writer.(interface{ PermissionLevel() int }); ok {
+		return po.PermissionLevel()
+	}
+
+	return mc.MaxPermissionLevel
+}
+
+// AllowsCommand reports whether the client that is sending commands to the
+// console may run commands of the given type, regardless of its permission
+// level.
+func (console *Console) AllowsCommand(ct mc.CommandType) bool {
+	if ao, ok := console.Writer.(interface{ AllowsCommand(mc.CommandType) bool }); ok {
+		return ao.AllowsCommand(ct)
+	}
+
+	return true
+}
+
 // Setup begins the input scanner loop for the console.
 func (console *Console) Setup() {
 	go console.scan()
@@ -53,19 +116,93 @@ func (console *Console) Setup() {
 // console is stopped.
 func (console *Console) Run() {
 	<-console.ctxStarted
-	log.Infof("Accepting console commands from %s", console.Name())
+	console.log.Info("Accepting console commands")
 
 	<-console.Done()
 }
 
-func (console *Console) Cleanup() {}
+// Cleanup closes the console's line or request reader, if it needs to be
+// closed.
+func (console *Console) Cleanup() {
+	var reader interface{} = console.LineReader
+	if console.requests != nil {
+		reader = console.requests
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			console.log.WithError(err).Error("Error closing console")
+		}
+	}
+}
 
 func (console *Console) scan() {
-	for console.Scan() {
-		console.Commands <- mc.NewCommand(console, strings.Split(console.Text(), " ")...)
+	if console.requests != nil {
+		console.scanRequests()
+		return
+	}
+
+	for {
+		line, err := console.ReadLine()
+		if err == io.EOF {
+			if console.stopOnEOF {
+				console.log.Info("Reached end of input")
+				console.Commands <- mc.NewCommand(console, mc.StopCommandName)
+			}
+
+			return
+		} else if err == ErrInterrupt {
+			console.Commands <- mc.NewCommand(console, mc.StopCommandName)
+			return
+		} else if err != nil {
+			console.log.WithError(err).Error("Error reading input from console")
+			return
+		}
+
+		console.handle(console, line)
+	}
+}
+
+// handle runs a line of input from the origin, and waits for the resulting
+// command to finish.
+func (console *Console) handle(origin mc.Origin, line string) {
+	cmd, err := mc.ParseCommand(origin, line)
+	if err == mc.ErrEmptyCommand {
+		return
+	} else if err != nil {
+		if _, werr := origin.Write([]byte(err.Error())); werr != nil {
+			console.log.WithError(werr).Error("Error responding to console input")
+		}
+
+		return
 	}
 
-	if err := console.Err(); err != nil {
-		log.Errorf("Error reading input from console: %s", err)
+	select {
+	case console.Commands <- cmd:
+	case <-console.Done():
+		return
 	}
+
+	select {
+	case <-cmd.Done():
+	case <-console.Done():
+	}
+}
+
+// scanner is a LineReader of newline-delimited input.
+type scanner struct {
+	*bufio.Scanner
+}
+
+// ReadLine returns the next line of input, or io.EOF at the end of the input.
+func (sc *scanner) ReadLine() (string, error) {
+	if sc.Scan() {
+		return sc.Text(), nil
+	}
+
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
 }
@@ -0,0 +1,122 @@
+package console
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// history is the command history of the full-screen interface. It is
+// persisted to HistoryFile in the same format as the history of the Terminal,
+// one command per line, so that both modes share it.
+type history struct {
+	path  string
+	lines []string
+}
+
+// loadHistory reads the history file at the path, keeping the last
+// HistoryLimit commands. A history file that cannot be read is treated as
+// empty, as the history is a convenience that must not prevent the console
+// from starting.
+func loadHistory(path string) *history {
+	h := &history{path: path, lines: make([]string, 0)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer file.Close()
+
+	total := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			h.lines = append(h.lines, line)
+			total++
+		}
+	}
+
+	if over := len(h.lines) - HistoryLimit; over > 0 {
+		h.lines = h.lines[over:]
+	}
+
+	if total > HistoryLimit {
+		h.rewrite()
+	}
+
+	return h
+}
+
+// Len returns the number of commands in the history.
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+// Get returns the command at the index, with the oldest command at index 0.
+func (h *history) Get(i int) string {
+	return h.lines[i]
+}
+
+// Add appends a command to the history and its file, unless it is empty or
+// repeats the previous command.
+func (h *history) Add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+
+	h.lines = append(h.lines, line)
+	if over := len(h.lines) - HistoryLimit; over > 0 {
+		h.lines = h.lines[over:]
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	file.WriteString(line + "\n")
+}
+
+// Search returns the index of the most recent command before the index that
+// contains the query, ignoring case, or -1 if there is none.
+func (h *history) Search(query string, before int) int {
+	query = strings.ToLower(query)
+
+	if before > len(h.lines) {
+		before = len(h.lines)
+	}
+
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(h.lines[i]), query) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// rewrite replaces the history file with the commands that are kept.
+func (h *history) rewrite() {
+	tmp := h.path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	buf := bufio.NewWriter(file)
+	for _, line := range h.lines {
+		buf.WriteString(line + "\n")
+	}
+
+	if err := buf.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return
+	}
+
+	file.Close()
+	os.Rename(tmp, h.path)
+}
@@ -5,21 +5,20 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// TermFormatter formats log entries for output to an attached TTY. Each entry
+// begins by clearing the current line, so that partially entered command input
+// is not mixed with log output; the Terminal redraws the input afterwards.
+// Minecraft formatting in messages is rendered as ANSI escape sequences.
 type TermFormatter struct {
 	*log.TextFormatter
 }
 
+// Format renders a single log entry.
 func (tf *TermFormatter) Format(entry *log.Entry) ([]byte, error) {
-	defer print("> ")
-
-	bytes, err := tf.TextFormatter.Format(entry)
+	bytes, err := (&ChatFormatter{Formatter: tf.TextFormatter, ANSI: true}).Format(entry)
 	if err != nil {
 		return nil, err
 	}
 
-	reset := []byte(tm.RESET_LINE)
-	bytes = append(reset, bytes...)
-	bytes = append(bytes, []byte("> ")...)
-
-	return bytes, nil
+	return append([]byte(tm.RESET_LINE), bytes...), nil
 }
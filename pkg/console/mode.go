@@ -0,0 +1,75 @@
+package console
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Mode selects how the server accepts commands from its standard input.
+type Mode string
+
+const (
+	// AutoMode uses TerminalMode if standard input is a TTY, and NoMode
+	// otherwise.
+	AutoMode Mode = "auto"
+
+	// TerminalMode reads commands from a line editor on an attached TTY.
+	TerminalMode Mode = "terminal"
+
+	// TUIMode shows a full-screen interface with a scrolling log pane and a
+	// status bar on an attached TTY, falling back to printing log lines above
+	// the input line like TerminalMode while the terminal is too small.
+	TUIMode Mode = "tui"
+
+	// StdinMode reads newline-delimited commands from standard input, whether
+	// or not it is a TTY, and writes plain responses to standard output.
+	StdinMode Mode = "stdin"
+
+	// NoMode does not read commands from standard input.
+	NoMode Mode = "none"
+)
+
+// InputMode is the mode in which the server accepts commands from its standard
+// input.
+var InputMode = AutoMode
+
+// StopOnEOF indicates whether the server stops when it reaches the end of its
+// standard input in StdinMode.
+var StopOnEOF = false
+
+// Resolve returns the mode to use for the process's standard input.
+func (mode Mode) Resolve() Mode {
+	switch mode {
+	case AutoMode:
+		if isatty.IsTerminal(os.Stdin.Fd()) {
+			return TerminalMode
+		}
+
+		return NoMode
+	}
+
+	return mode
+}
+
+// String returns the name of the mode.
+func (mode *Mode) String() string {
+	return string(*mode)
+}
+
+// Set sets the mode from its name.
+func (mode *Mode) Set(name string) error {
+	switch m := Mode(name); m {
+	case AutoMode, TerminalMode, TUIMode, StdinMode, NoMode:
+		*mode = m
+		return nil
+	}
+
+	return fmt.Errorf("Invalid console mode '%s'", name)
+}
+
+// Type returns the name of the mode's type for usage messages.
+func (mode *Mode) Type() string {
+	return "mode"
+}
@@ -0,0 +1,70 @@
+package console
+
+import (
+	"context"
+	"io"
+)
+
+// Request is a line of input that is handled independently of any other
+// input, such as an RCON command packet. Output for the request is written to
+// it, and Respond is called once all of its output has been written.
+type Request interface {
+	io.Writer
+	Line() string
+	Respond() error
+}
+
+// RequestReader reads requests that may be handled concurrently, with their
+// responses sent in the order in which they finish.
+type RequestReader interface {
+	ReadRequest() (Request, error)
+}
+
+// NewRequestConsole creates a console that reads requests from the reader, and
+// handles each one as soon as it is read.
+func NewRequestConsole(ctx context.Context, name string, reader RequestReader, writer io.Writer) (*Console, error) {
+	console, err := newConsole(ctx, name, nil, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	console.requests = reader
+	return console, nil
+}
+
+// requestOrigin is the origin of commands from a request, which collects the
+// output of the commands for the request, and is otherwise the same as the
+// console that read it.
+type requestOrigin struct {
+	*Console
+	Request
+}
+
+// Write writes output for the request.
+func (origin *requestOrigin) Write(p []byte) (int, error) {
+	return origin.Request.Write(p)
+}
+
+func (console *Console) scanRequests() {
+	for {
+		req, err := console.requests.ReadRequest()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			console.log.WithError(err).Error("Error reading input from console")
+			return
+		}
+
+		go console.handleRequest(req)
+	}
+}
+
+// handleRequest runs the line of input of the request, and responds to it once
+// the resulting command has finished.
+func (console *Console) handleRequest(req Request) {
+	console.handle(&requestOrigin{Console: console, Request: req}, req.Line())
+
+	if err := req.Respond(); err != nil && console.Err() == nil {
+		console.log.WithError(err).Error("Error responding to console input")
+	}
+}
@@ -0,0 +1,132 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chzyer/readline"
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+// Prompt is the prompt displayed before command input on a terminal.
+const Prompt = "> "
+
+// HistoryFile is the file to which the command history of the terminal
+// console is persisted between restarts.
+var HistoryFile = ".console_history"
+
+// HistoryLimit is the maximum number of commands kept in the history file.
+const HistoryLimit = 1000
+
+// ErrInterrupt is returned by Terminal.ReadLine when Ctrl-C is pressed on an
+// empty line.
+var ErrInterrupt = errors.New("Interrupted")
+
+// Terminal is a line editor for command input on an attached TTY, with cursor
+// movement, history navigation and search, and tab completion. Output written
+// to it is printed above the line being edited, which is then redrawn.
+type Terminal struct {
+	*readline.Instance
+}
+
+// NewTerminal creates a line editor on the process's standard input and
+// output.
+func NewTerminal(completer mc.Completer) (*Terminal, error) {
+	config := &readline.Config{
+		Prompt:            Prompt,
+		HistoryFile:       HistoryFile,
+		HistoryLimit:      HistoryLimit,
+		HistorySearchFold: true,
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "^D",
+	}
+
+	if completer != nil {
+		config.AutoComplete = &autoCompleter{completer}
+	}
+
+	rl, err := readline.NewEx(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Terminal{rl}, nil
+}
+
+// ReadLine reads a line of input. Ctrl-C discards the line being edited, or
+// returns ErrInterrupt if the line is empty.
+func (term *Terminal) ReadLine() (string, error) {
+	for {
+		line, err := term.Readline()
+		if err == readline.ErrInterrupt {
+			if line == "" {
+				return "", ErrInterrupt
+			}
+
+			continue
+		}
+
+		return line, err
+	}
+}
+
+// NewTerminalConsole creates a console that reads commands from a line editor
+// on the process's standard input.
+func NewTerminalConsole(ctx context.Context, name string, writer io.Writer) (*Console, *Terminal, error) {
+	completer, _ := ctx.Value(mc.ServerCompleter).(mc.Completer)
+
+	term, err := NewTerminal(completer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	console, err := newConsole(ctx, name, term, writer)
+	if err != nil {
+		term.Close()
+		return nil, nil, err
+	}
+
+	return console, term, nil
+}
+
+type autoCompleter struct {
+	mc.Completer
+}
+
+// Do returns the suffixes that complete the argument at the cursor, and the
+// length of the part of the argument that has already been typed. Suggestions
+// match the typed part regardless of case, as player names do; the line editor
+// can only insert the suffix, so the typed part keeps its case.
+func (ac *autoCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	input := string(line[:pos])
+	suggestions := ac.Complete(input, len(input))
+	typed := input[suggestions.Start:]
+
+	suffixes := make([][]rune, 0, len(suggestions.Matches))
+	for _, match := range suggestions.Matches {
+		if suffix, ok := cutFoldPrefix(match, typed); ok {
+			suffixes = append(suffixes, []rune(suffix+" "))
+		}
+	}
+
+	return suffixes, utf8.RuneCountInString(typed)
+}
+
+// cutFoldPrefix returns s without the prefix and true if s begins with the
+// prefix, comparing them rune by rune regardless of case, as the case of a rune
+// may be encoded in a different number of bytes than the rune itself.
+func cutFoldPrefix(s string, prefix string) (string, bool) {
+	for _, p := range prefix {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || !strings.EqualFold(string(r), string(p)) {
+			return "", false
+		}
+
+		s = s[size:]
+	}
+
+	return s, true
+}
@@ -0,0 +1,85 @@
+package console
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+// staticCompleter suggests the same matches for the argument at the end of
+// any input.
+type staticCompleter []string
+
+func (matches staticCompleter) Complete(input string, cursor int) *mc.Suggestions {
+	start := 0
+	for i := cursor - 1; i >= 0; i-- {
+		if input[i] == ' ' {
+			start = i + 1
+			break
+		}
+	}
+
+	return &mc.Suggestions{Start: start, Length: cursor - start, Matches: matches}
+}
+
+func TestAutoCompleterDo(t *testing.T) {
+	ac := &autoCompleter{staticCompleter{"Steve", "steven", "Ⱥlex", "Kelvin", "Émile", "@a"}}
+
+	tests := []struct {
+		line     string
+		suffixes []string
+		length   int
+	}{
+		{"say ", []string{"Steve ", "steven ", "Ⱥlex ", "Kelvin ", "Émile ", "@a "}, 0},
+		{"tellraw st", []string{"eve ", "even "}, 2},
+		{"tellraw STEVE", []string{" ", "n "}, 5},
+		{"tellraw ⱥ", []string{"lex "}, 1},
+		{"tellraw ⱥL", []string{"ex "}, 2},
+		{"tellraw K", []string{"elvin "}, 1},
+		{"tellraw ſt", []string{"eve ", "even "}, 2},
+		{"tellraw é", []string{"mile "}, 1},
+		{"tellraw x", []string{}, 1},
+		{"tellraw Stevens", []string{}, 7},
+	}
+
+	for _, test := range tests {
+		line := []rune(test.line)
+		suffixes, length := ac.Do(line, len(line))
+
+		strs := make([]string, len(suffixes))
+		for i, suffix := range suffixes {
+			strs[i] = string(suffix)
+		}
+
+		if !reflect.DeepEqual(strs, test.suffixes) || length != test.length {
+			t.Errorf("Do(%q) = %q, %d, expected %q, %d", test.line, strs, length, test.suffixes, test.length)
+		}
+	}
+}
+
+func TestCutFoldPrefix(t *testing.T) {
+	tests := []struct {
+		s, prefix string
+		rest      string
+		ok        bool
+	}{
+		{"Steve", "", "Steve", true},
+		{"Steve", "st", "eve", true},
+		{"Steve", "Steve", "", true},
+		{"Steve", "Steven", "", false},
+		{"Ⱥlex", "ⱥ", "lex", true},
+		{"ⱥlex", "Ⱥ", "lex", true},
+		{"Kelvin", "K", "elvin", true},
+		{"Steve", "ſ", "teve", true},
+		{"Émile", "e", "", false},
+		{"", "a", "", false},
+	}
+
+	for _, test := range tests {
+		rest, ok := cutFoldPrefix(test.s, test.prefix)
+		if rest != test.rest || ok != test.ok {
+			t.Errorf("cutFoldPrefix(%q, %q) = %q, %v, expected %q, %v", test.s, test.prefix, rest, ok, test.rest, test.ok)
+		}
+	}
+}
@@ -0,0 +1,718 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	tm "github.com/buger/goterm"
+	"github.com/chzyer/readline"
+	"github.com/jbhannah/gophermine/pkg/mc"
+)
+
+// Minimum terminal dimensions for the full-screen interface. While the
+// terminal is smaller, the interface falls back to printing log lines above
+// the input line, in the same way as TerminalMode.
+const (
+	TUIMinWidth  = 40
+	TUIMinHeight = 8
+)
+
+// TUIScrollback is the number of log lines kept for scrolling in the
+// full-screen interface.
+const TUIScrollback = 5000
+
+// TUIRefresh is the interval at which the status bar is refreshed.
+const TUIRefresh = time.Second
+
+// StatusFunc returns the text of the status bar of the full-screen interface.
+type StatusFunc func() string
+
+// TUI is a full-screen terminal interface with a scrolling log pane, a status
+// bar, and a fixed command input line. The command history is shared with
+// TerminalMode, and can be searched with Ctrl-R.
+type TUI struct {
+	completer mc.Completer
+	status    StatusFunc
+	state     *readline.State
+	out       *bufio.Writer
+	history   *history
+
+	mu      sync.Mutex
+	logs    []string
+	partial string
+	scroll  int
+	input   []rune
+	cursor  int
+	histPos int
+	search  *historySearch
+	width   int
+	height  int
+	full    bool
+
+	lines chan string
+	errs  chan error
+	done  chan struct{}
+	once  sync.Once
+}
+
+// historySearch is an incremental reverse search of the command history.
+type historySearch struct {
+	query []rune
+	match int
+	saved []rune
+}
+
+// NewTUI switches the attached terminal to the full-screen interface.
+func NewTUI(completer mc.Completer, status StatusFunc) (*TUI, error) {
+	state, err := readline.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	tui := &TUI{
+		completer: completer,
+		status:    status,
+		state:     state,
+		out:       bufio.NewWriter(os.Stdout),
+		history:   loadHistory(HistoryFile),
+		logs:      make([]string, 0),
+		lines:     make(chan string),
+		errs:      make(chan error, 1),
+		done:      make(chan struct{}),
+	}
+
+	tui.histPos = tui.history.Len()
+	tui.render()
+
+	go tui.readKeys()
+	go tui.refresh()
+
+	return tui, nil
+}
+
+// ReadLine returns the next line of command input. Ctrl-C on an empty line
+// returns ErrInterrupt, and Ctrl-D on an empty line returns io.EOF.
+func (tui *TUI) ReadLine() (string, error) {
+	select {
+	case line := <-tui.lines:
+		return line, nil
+	case err := <-tui.errs:
+		return "", err
+	case <-tui.done:
+		return "", io.EOF
+	}
+}
+
+// Write appends output to the log pane.
+func (tui *TUI) Write(p []byte) (int, error) {
+	tui.mu.Lock()
+	defer tui.mu.Unlock()
+
+	text := tui.partial + strings.Replace(string(p), tm.RESET_LINE, "", -1)
+	lines := strings.Split(text, "\n")
+	tui.partial = lines[len(lines)-1]
+
+	tui.layout()
+	for _, line := range lines[:len(lines)-1] {
+		tui.appendLog(strings.TrimRight(line, "\r"))
+	}
+
+	tui.draw()
+	return len(p), nil
+}
+
+// appendLog adds a line to the log pane, or prints it above the input line if
+// the terminal is too small for the full-screen interface. It must be called
+// with the lock held.
+func (tui *TUI) appendLog(line string) {
+	tui.logs = append(tui.logs, line)
+	if over := len(tui.logs) - TUIScrollback; over > 0 {
+		tui.logs = tui.logs[over:]
+	}
+
+	if tui.scroll > 0 {
+		tui.scroll++
+	}
+
+	if !tui.full {
+		tui.out.WriteString("\r\033[K")
+		tui.out.WriteString(line)
+		tui.out.WriteString(tm.RESET + "\r\n")
+	}
+}
+
+// Close restores the terminal to its state before the interface was started.
+func (tui *TUI) Close() error {
+	var err error
+
+	tui.once.Do(func() {
+		close(tui.done)
+
+		tui.mu.Lock()
+		defer tui.mu.Unlock()
+
+		if tui.full {
+			tui.out.WriteString("\033[?1049l")
+		} else {
+			tui.out.WriteString("\r\033[K")
+		}
+
+		tui.out.Flush()
+
+		err = readline.Restore(int(os.Stdin.Fd()), tui.state)
+	})
+
+	return err
+}
+
+func (tui *TUI) refresh() {
+	ticker := time.NewTicker(TUIRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-tui.done:
+			return
+		case <-ticker.C:
+			tui.render()
+		}
+	}
+}
+
+func (tui *TUI) render() {
+	tui.mu.Lock()
+	defer tui.mu.Unlock()
+
+	tui.draw()
+}
+
+// layout reads the size of the terminal, and switches between the
+// full-screen interface and the fallback for small terminals if the terminal
+// has been resized across the minimum dimensions. It must be called with the
+// lock held.
+func (tui *TUI) layout() {
+	tui.width, tui.height = tm.Width(), tm.Height()
+	full := tui.width >= TUIMinWidth && tui.height >= TUIMinHeight
+
+	switch {
+	case full && !tui.full:
+		// Switch to the alternate screen buffer, so that the shell's contents
+		// are restored when the interface is closed.
+		tui.out.WriteString("\r\033[K\033[?1049h")
+	case !full && tui.full:
+		tui.out.WriteString("\033[?1049l")
+	}
+
+	tui.full = full
+}
+
+// draw redraws the whole screen, or only the input line if the terminal is too
+// small for the full-screen interface. The size of the terminal is checked on
+// every redraw, so that the interface follows resizes. It must be called with
+// the lock held.
+func (tui *TUI) draw() {
+	select {
+	case <-tui.done:
+		return
+	default:
+	}
+
+	tui.layout()
+	if !tui.full {
+		line, col := tui.inputLine()
+		fmt.Fprintf(tui.out, "\r%s\033[K\r", line)
+		if col > 0 {
+			fmt.Fprintf(tui.out, "\033[%dC", col)
+		}
+
+		tui.out.Flush()
+		return
+	}
+
+	// Leave the last row for input, and the row above it for the status bar
+	// if there is room for any log lines at all.
+	paneHeight := tui.height - 1
+	showStatus := tui.height >= 3
+	if showStatus {
+		paneHeight--
+	}
+
+	maxScroll := len(tui.logs) - paneHeight
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	if tui.scroll > maxScroll {
+		tui.scroll = maxScroll
+	}
+
+	end := len(tui.logs) - tui.scroll
+	start := end - paneHeight
+	if start < 0 {
+		start = 0
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString("\033[?25l\033[H")
+
+	for row := 0; row < paneHeight; row++ {
+		if i := start + row; i < end {
+			buf.WriteString(truncateVisible(tui.logs[i], tui.width))
+			buf.WriteString(tm.RESET)
+		}
+
+		buf.WriteString("\033[K\r\n")
+	}
+
+	if showStatus {
+		status := ""
+		if tui.status != nil {
+			status = tui.status()
+		}
+
+		if tui.scroll > 0 {
+			status = fmt.Sprintf("[+%d] %s", tui.scroll, status)
+		}
+
+		status = truncateVisible(status, tui.width)
+		buf.WriteString("\033[7m")
+		buf.WriteString(status)
+		buf.WriteString(strings.Repeat(" ", tui.width-utf8.RuneCountInString(status)))
+		buf.WriteString("\033[0m\r\n")
+	}
+
+	line, col := tui.inputLine()
+	buf.WriteString(line)
+	buf.WriteString("\033[K")
+	fmt.Fprintf(buf, "\033[%d;%dH\033[?25h", tui.height, col+1)
+
+	tui.out.WriteString(buf.String())
+	tui.out.Flush()
+}
+
+// inputLine returns the prompt and the part of the input that fits on the
+// input line, and the column of the cursor. During a history search, the
+// prompt shows the query and the input is the matching command. It must be
+// called with the lock held.
+func (tui *TUI) inputLine() (string, int) {
+	prompt, input, cursor := Prompt, tui.input, tui.cursor
+
+	if search := tui.search; search != nil {
+		prompt = fmt.Sprintf("(reverse-i-search)`%s': ", string(search.query))
+		input, cursor = nil, 0
+
+		if search.match >= 0 {
+			input = []rune(tui.history.Get(search.match))
+			cursor = matchIndex(input, search.query)
+		} else if len(search.query) > 0 {
+			prompt = "(failed " + prompt[1:]
+		}
+	}
+
+	promptWidth := utf8.RuneCountInString(prompt)
+
+	// Scroll the input horizontally to keep the cursor on screen.
+	inputWidth := tui.width - promptWidth - 1
+	offset := 0
+	if inputWidth > 0 && cursor > inputWidth {
+		offset = cursor - inputWidth
+	}
+
+	visible := input[offset:]
+	if inputWidth >= 0 && len(visible) > inputWidth+1 {
+		visible = visible[:inputWidth+1]
+	}
+
+	return prompt + string(visible), promptWidth + cursor - offset
+}
+
+// matchIndex returns the index in runes of the first match of the query in the
+// line, ignoring case, or 0 if there is none.
+func matchIndex(line []rune, query []rune) int {
+	lower := []rune(strings.ToLower(string(line)))
+	if len(lower) != len(line) {
+		return 0
+	}
+
+	q := strings.ToLower(string(query))
+	for i := range lower {
+		if strings.HasPrefix(string(lower[i:]), q) {
+			return i
+		}
+	}
+
+	return 0
+}
+
+func (tui *TUI) readKeys() {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			tui.errs <- err
+			return
+		}
+
+		select {
+		case <-tui.done:
+			return
+		default:
+		}
+
+		if r == '\033' {
+			r = readEscape(reader)
+		}
+
+		tui.mu.Lock()
+		line, submit, err := tui.handleKey(r)
+		tui.draw()
+		tui.mu.Unlock()
+
+		if err != nil {
+			tui.errs <- err
+			return
+		}
+
+		if submit {
+			select {
+			case tui.lines <- line:
+			case <-tui.done:
+				return
+			}
+		}
+	}
+}
+
+// Keys decoded from escape sequences, using values from the Unicode private
+// use area.
+const (
+	keyUp rune = 0xe000 + iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyPageUp
+	keyPageDown
+	keyEscape
+	keyUnknown
+)
+
+// readEscape decodes the escape sequence that follows an escape character.
+// Terminals send escape sequences in a single write, so an escape character
+// with no input buffered after it is a press of the Esc key, and the next key
+// is not waited for.
+func readEscape(reader *bufio.Reader) rune {
+	if reader.Buffered() == 0 {
+		return keyEscape
+	}
+
+	r, _, err := reader.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return keyUnknown
+	}
+
+	seq := make([]rune, 0, 4)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return keyUnknown
+		}
+
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		return keyUp
+	case "B":
+		return keyDown
+	case "C":
+		return keyRight
+	case "D":
+		return keyLeft
+	case "H", "1~", "7~":
+		return keyHome
+	case "F", "4~", "8~":
+		return keyEnd
+	case "3~":
+		return keyDelete
+	case "5~":
+		return keyPageUp
+	case "6~":
+		return keyPageDown
+	}
+
+	return keyUnknown
+}
+
+// handleKey applies a key press to the input line. It must be called with the
+// lock held.
+func (tui *TUI) handleKey(r rune) (string, bool, error) {
+	if tui.search != nil && tui.handleSearchKey(r) {
+		return "", false, nil
+	}
+
+	page := tui.height - 2
+	if page < 1 {
+		page = 1
+	}
+
+	switch r {
+	case '\r', '\n':
+		line := string(tui.input)
+		tui.history.Add(line)
+
+		tui.histPos = tui.history.Len()
+		tui.input, tui.cursor, tui.scroll = tui.input[:0], 0, 0
+
+		return line, true, nil
+	case 0x03:
+		if len(tui.input) == 0 {
+			return "", false, ErrInterrupt
+		}
+
+		tui.input, tui.cursor = tui.input[:0], 0
+	case 0x04:
+		if len(tui.input) == 0 {
+			return "", false, io.EOF
+		}
+
+		fallthrough
+	case keyDelete:
+		if tui.cursor < len(tui.input) {
+			tui.input = append(tui.input[:tui.cursor], tui.input[tui.cursor+1:]...)
+		}
+	case 0x7f, 0x08:
+		if tui.cursor > 0 {
+			tui.input = append(tui.input[:tui.cursor-1], tui.input[tui.cursor:]...)
+			tui.cursor--
+		}
+	case 0x01, keyHome:
+		tui.cursor = 0
+	case 0x05, keyEnd:
+		tui.cursor = len(tui.input)
+	case 0x02, keyLeft:
+		if tui.cursor > 0 {
+			tui.cursor--
+		}
+	case 0x06, keyRight:
+		if tui.cursor < len(tui.input) {
+			tui.cursor++
+		}
+	case 0x15:
+		tui.input = append(tui.input[:0], tui.input[tui.cursor:]...)
+		tui.cursor = 0
+	case 0x0b:
+		tui.input = tui.input[:tui.cursor]
+	case 0x10, keyUp:
+		if tui.histPos > 0 {
+			tui.histPos--
+			tui.setInput(tui.history.Get(tui.histPos))
+		}
+	case 0x0e, keyDown:
+		if tui.histPos < tui.history.Len()-1 {
+			tui.histPos++
+			tui.setInput(tui.history.Get(tui.histPos))
+		} else {
+			tui.histPos = tui.history.Len()
+			tui.setInput("")
+		}
+	case 0x12:
+		tui.search = &historySearch{
+			match: -1,
+			saved: append([]rune(nil), tui.input...),
+		}
+	case keyPageUp:
+		tui.scroll += page
+	case keyPageDown:
+		if tui.scroll -= page; tui.scroll < 0 {
+			tui.scroll = 0
+		}
+	case '\t':
+		tui.complete()
+	default:
+		if r >= 0x20 && r < keyUp {
+			tui.input = append(tui.input, 0)
+			copy(tui.input[tui.cursor+1:], tui.input[tui.cursor:])
+			tui.input[tui.cursor] = r
+			tui.cursor++
+		}
+	}
+
+	return "", false, nil
+}
+
+// handleSearchKey applies a key press to the history search, and reports
+// whether it was handled. Ctrl-R finds the next older match, and Esc, Ctrl-G
+// and Ctrl-C cancel the search. Any other key that does not edit the query
+// accepts the match and ends the search, and is then handled as usual. It must
+// be called with the lock held.
+func (tui *TUI) handleSearchKey(r rune) bool {
+	search := tui.search
+
+	switch r {
+	case 0x12:
+		before := tui.history.Len()
+		if search.match >= 0 {
+			before = search.match
+		}
+
+		if match := tui.history.Search(string(search.query), before); match >= 0 || search.match < 0 {
+			search.match = match
+		}
+
+		return true
+	case 0x7f, 0x08:
+		if len(search.query) > 0 {
+			search.query = search.query[:len(search.query)-1]
+			search.match = tui.history.Search(string(search.query), tui.history.Len())
+		}
+
+		return true
+	case 0x03, 0x07, keyEscape:
+		tui.search = nil
+		tui.setInput(string(search.saved))
+
+		return true
+	}
+
+	if r >= 0x20 && r < keyUp {
+		search.query = append(search.query, r)
+
+		before := tui.history.Len()
+		if search.match >= 0 {
+			before = search.match + 1
+		}
+
+		search.match = tui.history.Search(string(search.query), before)
+		return true
+	}
+
+	tui.search = nil
+	if search.match >= 0 {
+		tui.histPos = search.match
+		tui.setInput(tui.history.Get(search.match))
+	} else {
+		tui.setInput(string(search.saved))
+	}
+
+	return false
+}
+
+func (tui *TUI) setInput(line string) {
+	tui.input = []rune(line)
+	tui.cursor = len(tui.input)
+}
+
+// complete applies the only suggestion for the argument at the cursor, or the
+// longest prefix shared by all suggestions, and lists the suggestions in the
+// log pane if there is more than one.
+func (tui *TUI) complete() {
+	if tui.completer == nil {
+		return
+	}
+
+	input := string(tui.input[:tui.cursor])
+	suggestions := tui.completer.Complete(input, len(input))
+
+	if len(suggestions.Matches) == 0 {
+		return
+	}
+
+	common := suggestions.Matches[0]
+	for _, match := range suggestions.Matches[1:] {
+		for !strings.HasPrefix(match, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	if len(suggestions.Matches) == 1 {
+		common += " "
+	} else {
+		tui.appendLog(strings.Join(suggestions.Matches, "  "))
+	}
+
+	if len(common) < suggestions.Length {
+		return
+	}
+
+	rest := string(tui.input[tui.cursor:])
+	completed := input[:suggestions.Start] + common
+
+	tui.input = []rune(completed + rest)
+	tui.cursor = utf8.RuneCountInString(completed)
+}
+
+// truncateVisible truncates a string containing ANSI escape sequences to the
+// given number of visible characters.
+func truncateVisible(s string, width int) string {
+	buf := &strings.Builder{}
+	visible := 0
+
+	for i := 0; i < len(s); {
+		if s[i] == '\033' {
+			j := i + 1
+			if j < len(s) && s[j] == '[' {
+				for j++; j < len(s) && (s[j] < 0x40 || s[j] > 0x7e); j++ {
+				}
+			}
+
+			if j < len(s) {
+				j++
+			}
+
+			buf.WriteString(s[i:j])
+			i = j
+
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible >= width {
+			i += size
+			continue
+		}
+
+		if r == '\t' {
+			r = ' '
+		}
+
+		buf.WriteRune(r)
+		visible++
+		i += size
+	}
+
+	return buf.String()
+}
+
+// NewTUIConsole creates a console that reads commands from the full-screen
+// interface.
+func NewTUIConsole(ctx context.Context, name string, writer io.Writer, status StatusFunc) (*Console, *TUI, error) {
+	completer, _ := ctx.Value(mc.ServerCompleter).(mc.Completer)
+
+	tui, err := NewTUI(completer, status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	console, err := newConsole(ctx, name, tui, writer)
+	if err != nil {
+		tui.Close()
+		return nil, nil, err
+	}
+
+	return console, tui, nil
+}
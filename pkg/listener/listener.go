@@ -2,14 +2,22 @@ package listener
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/runner"
 	log "github.com/sirupsen/logrus"
 )
 
+// RebindRetryInterval is how often a listener that lost its address while
+// moving to a new one tries to bind its previous address again.
+const RebindRetryInterval = 5 * time.Second
+
 // Handler defines the interface for handlers of incoming network connections.
 type Handler interface {
 	HandleConn(net.Conn)
@@ -21,69 +29,134 @@ type Listener struct {
 	Handler
 	net.Listener
 	*runner.Runner
+	log     *log.Entry
 	stopped chan struct{}
+	closing chan struct{}
 	wg      *sync.WaitGroup
+	tls     *tls.Config
+	rebind  chan rebindRequest
+	mu      sync.RWMutex
+
+	// lost is the address that the listener could not bind again after
+	// failing to move to a new one, and retry fires when to try again.
+	lost  string
+	retry <-chan time.Time
+}
+
+// rebindRequest asks the listener loop to move the listener to a new address.
+type rebindRequest struct {
+	addr string
+	err  chan error
 }
 
 // NewListener creates a new listener at the given address.
 func NewListener(ctx context.Context, handler Handler, addr string) (*Listener, error) {
-	listen, err := net.Listen("tcp", addr)
+	return newListener(ctx, handler, addr, nil)
+}
+
+// NewTLSListener creates a new listener at the given address that wraps
+// incoming connections in TLS with the given configuration.
+func NewTLSListener(ctx context.Context, handler Handler, addr string, config *tls.Config) (*Listener, error) {
+	listener, err := newListener(ctx, handler, addr, config)
 	if err != nil {
-		return nil, fmt.Errorf("Could not listen on %s for %s: %v", addr, handler.Name(), err)
+		return nil, err
 	}
 
+	listener.log = listener.log.WithField("tls", true)
+	return listener, nil
+}
+
+func newListener(ctx context.Context, handler Handler, addr string, config *tls.Config) (*Listener, error) {
 	listener := &Listener{
-		Handler:  handler,
-		Listener: listen,
-		stopped:  make(chan struct{}),
-		wg:       &sync.WaitGroup{},
+		Handler: handler,
+		log:     logs.Component(strings.ToLower(handler.Name())),
+		wg:      &sync.WaitGroup{},
+		tls:     config,
+		rebind:  make(chan rebindRequest),
+	}
+
+	listen, err := listener.bind(addr)
+	if err != nil {
+		return nil, err
 	}
 
+	listener.Listener = listen
 	listener.Runner = runner.NewRunner(ctx, listener)
+
 	return listener, nil
 }
 
+// Addr returns the address on which the listener is currently listening.
+func (listener *Listener) Addr() net.Addr {
+	listener.mu.RLock()
+	defer listener.mu.RUnlock()
+
+	return listener.Listener.Addr()
+}
+
+// Rebind moves the listener to a new address without closing the connections
+// it has already accepted. If the new address cannot be bound, the listener
+// keeps listening on its current address, or keeps trying to bind it again if
+// it had to be released.
+func (listener *Listener) Rebind(addr string) error {
+	req := rebindRequest{addr: addr, err: make(chan error, 1)}
+
+	select {
+	case listener.rebind <- req:
+		return <-req.err
+	case <-listener.Done():
+		return listener.Err()
+	}
+}
+
 // Setup starts the connection listening loop.
 func (listener *Listener) Setup() {
-	defer log.Infof("Listening on %s for %s", listener.Addr(), listener.Name())
-	go listener.listen()
+	defer listener.log.WithField(logs.AddrField, listener.Addr().String()).Info("Listening")
+	listener.start()
 }
 
-// Run restarts the listener if it stops unexpectedly.
+// Run restarts the listener if it stops unexpectedly, and moves it to new
+// addresses when requested.
 func (listener *Listener) Run() {
 	for {
 		select {
 		case <-listener.Done():
 			return
+		case req := <-listener.rebind:
+			req.err <- listener.move(req.addr)
+		case <-listener.retry:
+			listener.retry = nil
+			listener.restore()
 		case <-listener.stopped:
-			log.Warnf("Restarting listener for %s", listener.Name())
-			go listener.listen()
+			listener.log.Warn("Restarting listener")
+			listener.start()
 		}
 	}
 }
 
 // Cleanup closes the listener.
 func (listener *Listener) Cleanup() {
-	defer log.Debugf("Stopped listening on %s for %s", listener.Addr(), listener.Name())
-	log.Debugf("Stopping listener for %s", listener.Name())
+	defer listener.log.WithField(logs.AddrField, listener.Addr().String()).Debug("Stopped listening")
+	listener.log.Debug("Stopping listener")
 
 	listener.wg.Wait()
-	listener.Close()
-	<-listener.stopped
+	listener.close()
 }
 
-func (listener *Listener) handle(conn *net.TCPConn) {
+func (listener *Listener) handle(conn net.Conn) {
 	closed := make(chan struct{})
 	defer close(closed)
 
+	entry := listener.log.WithField(logs.RemoteAddrField, conn.RemoteAddr().String())
+
 	go func() {
-		defer log.Debugf("Closed connection for %s from %s", listener.Name(), conn.RemoteAddr())
+		defer entry.Debug("Closed connection")
 		defer listener.wg.Done()
 
 		select {
 		case <-listener.Done():
-			if err := conn.CloseRead(); err != nil {
-				log.Warnf("Unable to close connection for %s from %s nicely", listener.Name(), conn.RemoteAddr())
+			if err := closeRead(conn); err != nil {
+				entry.WithError(err).Warn("Unable to close connection nicely")
 			}
 		case <-closed:
 		}
@@ -93,20 +166,134 @@ func (listener *Listener) handle(conn *net.TCPConn) {
 	listener.HandleConn(conn)
 }
 
-func (listener *Listener) listen() {
-	defer close(listener.stopped)
+// bind opens a network listener at the address, wrapped in TLS if the
+// listener has a TLS configuration.
+func (listener *Listener) bind(addr string) (net.Listener, error) {
+	listen, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Could not listen on %s for %s: %v", addr, listener.Name(), err)
+	}
+
+	if listener.tls != nil {
+		listen = tls.NewListener(listen, listener.tls)
+	}
+
+	return listen, nil
+}
+
+// move replaces the network listener with one bound to the address. If the
+// address overlaps the current one, e.g. when only the host changes, it can
+// only be bound once the current listener is closed, so the current address is
+// bound again if the new one still cannot be bound. If neither can be bound,
+// the listener keeps trying to bind the current address until it succeeds or
+// is moved again.
+func (listener *Listener) move(addr string) error {
+	listen, err := listener.bind(addr)
+	if err != nil {
+		oldAddr := listener.Addr().String()
+		if listener.lost != "" {
+			oldAddr = listener.lost
+		}
+
+		listener.close()
+
+		if listen, err = listener.bind(addr); err != nil {
+			var rerr error
+			if listen, rerr = listener.bind(oldAddr); rerr != nil {
+				listener.log.WithField(logs.AddrField, oldAddr).WithError(rerr).Error("Could not listen on the previous address again, retrying")
+				listener.lost = oldAddr
+				listener.retry = time.After(RebindRetryInterval)
+
+				return err
+			}
+		}
+	}
+
+	listener.close()
+	listener.replace(listen)
+
+	return err
+}
+
+// restore tries to bind the address that the listener lost while moving, and
+// schedules another attempt if it still cannot be bound.
+func (listener *Listener) restore() {
+	listen, err := listener.bind(listener.lost)
+	if err != nil {
+		listener.log.WithField(logs.AddrField, listener.lost).WithError(err).Debug("Could not listen on the previous address again, retrying")
+		listener.retry = time.After(RebindRetryInterval)
+
+		return
+	}
+
+	listener.replace(listen)
+}
+
+// replace starts the listening loop of a new network listener in place of the
+// closed current one.
+func (listener *Listener) replace(listen net.Listener) {
+	listener.mu.Lock()
+	listener.Listener = listen
+	listener.mu.Unlock()
+
+	listener.lost = ""
+	listener.retry = nil
+
+	listener.start()
+	listener.log.WithField(logs.AddrField, listen.Addr().String()).Info("Listening")
+}
+
+// start starts the listening loop of the current network listener.
+func (listener *Listener) start() {
+	listener.stopped = make(chan struct{})
+	listener.closing = make(chan struct{})
+
+	go listener.listen(listener.Listener, listener.stopped, listener.closing)
+}
+
+// close closes the network listener, if it is still open, and waits for its
+// listening loop to stop.
+func (listener *Listener) close() {
+	if listener.stopped == nil {
+		return
+	}
+
+	close(listener.closing)
+	listener.Listener.Close()
+	<-listener.stopped
+
+	listener.stopped = nil
+}
+
+func (listener *Listener) listen(listen net.Listener, stopped chan<- struct{}, closing <-chan struct{}) {
+	defer close(stopped)
 
 	for {
-		conn, err := listener.Accept()
+		conn, err := listen.Accept()
 		if err != nil {
-			if listener.Err() == nil {
-				log.Errorf("Error accepting connection for %s: %s", listener.Name(), err)
+			select {
+			case <-closing:
+			default:
+				if listener.Err() == nil {
+					listener.log.WithError(err).Error("Error accepting connection")
+				}
 			}
 
 			return
 		}
 
-		log.Infof("Accepted connection for %s from %s", listener.Name(), conn.RemoteAddr())
-		go listener.handle(conn.(*net.TCPConn))
+		listener.log.WithField(logs.RemoteAddrField, conn.RemoteAddr().String()).Info("Accepted connection")
+		go listener.handle(conn)
 	}
 }
+
+// closeRead shuts down the reading side of a connection, so that its handler
+// sees the end of its input. TLS connections cannot be half closed, so reads
+// from them are interrupted by a deadline instead.
+func closeRead(conn net.Conn) error {
+	if cr, ok := conn.(interface{ CloseRead() error }); ok {
+		return cr.CloseRead()
+	}
+
+	return conn.SetReadDeadline(time.Now())
+}
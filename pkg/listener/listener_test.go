@@ -0,0 +1,135 @@
+package listener
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+type testHandler struct{}
+
+func (testHandler) HandleConn(conn net.Conn) { conn.Close() }
+func (testHandler) Name() string             { return "Test" }
+
+// stubListener is a network listener that claims to be bound to an address
+// that it does not hold, so that binding that address again fails.
+type stubListener struct {
+	addr   net.Addr
+	closed chan struct{}
+}
+
+func (stub *stubListener) Accept() (net.Conn, error) {
+	<-stub.closed
+	return nil, net.ErrClosed
+}
+
+func (stub *stubListener) Close() error {
+	close(stub.closed)
+	return nil
+}
+
+func (stub *stubListener) Addr() net.Addr {
+	return stub.addr
+}
+
+func occupy(t *testing.T) net.Listener {
+	t.Helper()
+
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listen.Close() })
+	return listen
+}
+
+func TestMoveLostAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := NewListener(ctx, testHandler{}, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend that the listener is bound to an address held by another
+	// socket, so that neither it nor the new address can be bound.
+	listener.Listener.Close()
+	old, taken := occupy(t), occupy(t)
+	listener.Listener = &stubListener{addr: old.Addr(), closed: make(chan struct{})}
+	listener.start()
+
+	if err := listener.move(taken.Addr().String()); err == nil {
+		t.Fatal("moved to an address that is in use")
+	}
+
+	if listener.stopped != nil || listener.lost != old.Addr().String() || listener.retry == nil {
+		t.Fatalf("listener is not waiting to bind %s again (lost %q)", old.Addr(), listener.lost)
+	}
+
+	listener.retry = nil
+	listener.restore()
+	if listener.retry == nil {
+		t.Fatal("listener did not schedule another attempt while the address is in use")
+	}
+
+	// Moving again while the address is lost keeps it as the address to
+	// return to.
+	if err := listener.move(taken.Addr().String()); err == nil {
+		t.Fatal("moved to an address that is in use")
+	}
+
+	if listener.lost != old.Addr().String() {
+		t.Fatalf("listener lost %q, expected %s", listener.lost, old.Addr())
+	}
+
+	old.Close()
+	listener.retry = nil
+	listener.restore()
+
+	if listener.stopped == nil || listener.lost != "" || listener.retry != nil {
+		t.Fatal("listener did not bind its previous address again")
+	}
+
+	if addr := listener.Addr().String(); addr != old.Addr().String() {
+		t.Fatalf("listener is listening on %s, expected %s", addr, old.Addr())
+	}
+
+	conn, err := net.Dial("tcp", old.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	listener.close()
+}
+
+func TestMoveAfterLostAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := NewListener(ctx, testHandler{}, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener.Listener.Close()
+	old, taken := occupy(t), occupy(t)
+	listener.Listener = &stubListener{addr: old.Addr(), closed: make(chan struct{})}
+	listener.start()
+
+	if err := listener.move(taken.Addr().String()); err == nil {
+		t.Fatal("moved to an address that is in use")
+	}
+
+	if err := listener.move("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if listener.stopped == nil || listener.lost != "" || listener.retry != nil {
+		t.Fatal("listener is still waiting to bind its previous address")
+	}
+
+	listener.close()
+}
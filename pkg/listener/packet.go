@@ -0,0 +1,101 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/runner"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxDatagramSize is the maximum size in bytes of an incoming datagram. Any
+// bytes beyond it are discarded.
+const MaxDatagramSize = 1460
+
+// PacketHandler defines the interface for handlers of incoming datagrams.
+// Responses are written to the packet connection that received the datagram.
+type PacketHandler interface {
+	HandlePacket(conn net.PacketConn, addr net.Addr, data []byte)
+	Name() string
+}
+
+// PacketListener performs handling of incoming datagrams, one at a time.
+type PacketListener struct {
+	PacketHandler
+	net.PacketConn
+	*runner.Runner
+	log     *log.Entry
+	stopped chan struct{}
+}
+
+// NewPacketListener creates a new UDP listener at the given address.
+func NewPacketListener(ctx context.Context, handler PacketHandler, addr string) (*PacketListener, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Could not listen on %s for %s: %v", addr, handler.Name(), err)
+	}
+
+	listener := &PacketListener{
+		PacketHandler: handler,
+		PacketConn:    conn,
+		log:           logs.Component(strings.ToLower(handler.Name())),
+		stopped:       make(chan struct{}),
+	}
+
+	listener.Runner = runner.NewRunner(ctx, listener)
+	return listener, nil
+}
+
+// Setup starts the datagram reading loop.
+func (listener *PacketListener) Setup() {
+	defer listener.log.WithField(logs.AddrField, listener.LocalAddr().String()).Info("Listening")
+	go listener.listen()
+}
+
+// Run restarts the listener if it stops unexpectedly.
+func (listener *PacketListener) Run() {
+	for {
+		select {
+		case <-listener.Done():
+			return
+		case <-listener.stopped:
+			listener.log.Warn("Restarting listener")
+			listener.stopped = make(chan struct{})
+			go listener.listen()
+		}
+	}
+}
+
+// Cleanup closes the listener.
+func (listener *PacketListener) Cleanup() {
+	defer listener.log.WithField(logs.AddrField, listener.LocalAddr().String()).Debug("Stopped listening")
+	listener.log.Debug("Stopping listener")
+
+	listener.Close()
+	<-listener.stopped
+}
+
+func (listener *PacketListener) listen() {
+	defer close(listener.stopped)
+
+	buf := make([]byte, MaxDatagramSize)
+
+	for {
+		n, addr, err := listener.ReadFrom(buf)
+		if err != nil {
+			if listener.Err() == nil {
+				listener.log.WithError(err).Error("Error reading datagram")
+			}
+
+			return
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		listener.HandlePacket(listener.PacketConn, addr, data)
+	}
+}
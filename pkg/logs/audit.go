@@ -0,0 +1,41 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CommandsLog is the name of the file to which executed commands are logged.
+const CommandsLog = "commands.log"
+
+// Audit is the logger of executed commands. It discards all entries until the
+// audit log file is opened.
+var Audit = &log.Logger{
+	Out:       ioutil.Discard,
+	Hooks:     make(log.LevelHooks),
+	Formatter: &log.JSONFormatter{},
+	Level:     log.InfoLevel,
+	ExitFunc:  os.Exit,
+}
+
+// OpenAuditLog opens the commands.log file in the given directory for
+// appending, and directs entries logged to Audit to it with the formatter.
+// Unlike latest.log, the audit log is never rolled over.
+func OpenAuditLog(dir string, formatter log.Formatter) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, CommandsLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	Audit.SetOutput(file)
+	Audit.SetFormatter(formatter)
+
+	return file, nil
+}
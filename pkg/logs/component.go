@@ -0,0 +1,182 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the fields attached to log entries.
+const (
+	ComponentField  = "component"
+	AddrField       = "addr"
+	DurationField   = "duration"
+	NameField       = "name"
+	RemoteAddrField = "remote_addr"
+	PlayerField     = "player"
+	UUIDField       = "uuid"
+)
+
+// output is the output of the standard logger and of every component logger.
+// Each logger only serializes its own writes, so writes to the shared output
+// are serialized here to keep lines from different loggers from interleaving.
+var output = &lockedWriter{Writer: os.Stderr}
+
+func init() {
+	log.SetOutput(output)
+}
+
+var components = &registry{
+	loggers: make(map[string]*log.Logger),
+	levels:  make(map[string]log.Level),
+}
+
+// registry holds the loggers of each component, and the levels of components
+// that override the level of the standard logger.
+type registry struct {
+	loggers map[string]*log.Logger
+	levels  map[string]log.Level
+	mu      sync.Mutex
+}
+
+// Component returns a log entry for the named subsystem. Entries logged by a
+// component are written with the output, formatter and hooks of the standard
+// logger, but are filtered by the level of the component.
+func Component(name string) *log.Entry {
+	components.mu.Lock()
+	defer components.mu.Unlock()
+
+	logger, ok := components.loggers[name]
+	if !ok {
+		logger = &log.Logger{
+			Out:       output,
+			Hooks:     log.StandardLogger().Hooks,
+			Formatter: stdFormatter{},
+			Level:     components.level(name),
+			ExitFunc:  os.Exit,
+		}
+
+		components.loggers[name] = logger
+	}
+
+	return logger.WithField(ComponentField, name)
+}
+
+// SetOutput sets the output of the standard logger and of all components. It
+// must be used instead of logrus.SetOutput, so that writes from all loggers
+// remain serialized.
+func SetOutput(writer io.Writer) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+
+	output.Writer = writer
+}
+
+// SetLevel sets the level of the standard logger, and of all components whose
+// level is not overridden.
+func SetLevel(level log.Level) {
+	components.mu.Lock()
+	defer components.mu.Unlock()
+
+	log.SetLevel(level)
+
+	for name, logger := range components.loggers {
+		logger.SetLevel(components.level(name))
+	}
+}
+
+// SetComponentLevel overrides the level of the named component.
+func SetComponentLevel(name string, level log.Level) {
+	components.mu.Lock()
+	defer components.mu.Unlock()
+
+	components.levels[name] = level
+
+	if logger, ok := components.loggers[name]; ok {
+		logger.SetLevel(level)
+	}
+}
+
+func (reg *registry) level(name string) log.Level {
+	if level, ok := reg.levels[name]; ok {
+		return level
+	}
+
+	return log.GetLevel()
+}
+
+// lockedWriter serializes writes to a writer that may be replaced.
+type lockedWriter struct {
+	io.Writer
+	mu sync.Mutex
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	return lw.Writer.Write(p)
+}
+
+// stdFormatter formats entries with the current formatter of the standard
+// logger.
+type stdFormatter struct{}
+
+func (stdFormatter) Format(entry *log.Entry) ([]byte, error) {
+	return log.StandardLogger().Formatter.Format(entry)
+}
+
+// Levels is the value of the --log-level flag: a comma-separated list of
+// component=level overrides, with an optional bare level for the default.
+var Levels levels
+
+type levels struct{}
+
+func (levels) String() string {
+	components.mu.Lock()
+	defer components.mu.Unlock()
+
+	overrides := make([]string, 0, len(components.levels))
+	for name, level := range components.levels {
+		overrides = append(overrides, fmt.Sprintf("%s=%s", name, level))
+	}
+
+	sort.Strings(overrides)
+	return strings.Join(overrides, ",")
+}
+
+func (levels) Set(value string) error {
+	for _, override := range strings.Split(value, ",") {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+
+		name, lvl := "", override
+		if i := strings.IndexByte(override, '='); i >= 0 {
+			name, lvl = override[:i], override[i+1:]
+		}
+
+		level, err := log.ParseLevel(lvl)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			SetLevel(level)
+		} else {
+			SetComponentLevel(name, level)
+		}
+	}
+
+	return nil
+}
+
+func (levels) Type() string {
+	return "levels"
+}
@@ -0,0 +1,184 @@
+package logs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Dir is the directory in which server log files are written.
+var Dir = "logs"
+
+// LatestLog is the name of the log file for the current day.
+const LatestLog = "latest.log"
+
+// dateFormat is the date format used in the names of rolled log files.
+const dateFormat = "2006-01-02"
+
+// File is a log file that is rolled over when it is opened and at midnight.
+// Rolled files are named after the day on which they were written and
+// compressed with gzip, e.g. logs/2019-08-01-1.log.gz.
+type File struct {
+	dir  string
+	file *os.File
+	day  time.Time
+	mu   sync.Mutex
+}
+
+// OpenFile rolls over any existing latest.log in the given directory, and
+// opens a new one for writing.
+func OpenFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	lf := &File{dir: dir}
+
+	if info, err := os.Stat(lf.latest()); err == nil {
+		if err := lf.roll(startOfDay(info.ModTime())); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := lf.open(os.O_TRUNC); err != nil {
+		return nil, err
+	}
+
+	return lf, nil
+}
+
+// Write writes to latest.log, first rolling it over if the day has changed
+// since it was opened. If it cannot be rolled over, the output is appended to
+// the existing latest.log, the error is returned once so that it is reported,
+// and rolling over is tried again the next day.
+func (lf *File) Write(p []byte) (int, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	var rollErr error
+	if today := startOfDay(time.Now()); today.After(lf.day) {
+		if err := lf.file.Close(); err != nil {
+			rollErr = err
+		} else if err := lf.roll(lf.day); err != nil {
+			rollErr = err
+		}
+
+		flag := os.O_TRUNC
+		if rollErr != nil {
+			flag = os.O_APPEND
+		}
+
+		if err := lf.open(flag); err != nil {
+			lf.file = nil
+			return 0, err
+		}
+	}
+
+	n, err := lf.file.Write(p)
+	if err == nil {
+		err = rollErr
+	}
+
+	return n, err
+}
+
+// Close closes latest.log. It is rolled over the next time the log file is
+// opened.
+func (lf *File) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.file == nil {
+		return nil
+	}
+
+	err := lf.file.Close()
+	lf.file = nil
+
+	return err
+}
+
+func (lf *File) latest() string {
+	return filepath.Join(lf.dir, LatestLog)
+}
+
+// open opens latest.log for writing with the additional flag, which is either
+// os.O_TRUNC to start a new file or os.O_APPEND to continue the existing one.
+func (lf *File) open(flag int) error {
+	file, err := os.OpenFile(lf.latest(), os.O_CREATE|os.O_WRONLY|flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	lf.file = file
+	lf.day = startOfDay(time.Now())
+
+	return nil
+}
+
+// roll compresses latest.log into the first unused archive name for the given
+// day, and removes it.
+func (lf *File) roll(day time.Time) error {
+	var name string
+	for n := 1; ; n++ {
+		name = filepath.Join(lf.dir, fmt.Sprintf("%s-%d.log.gz", day.Format(dateFormat), n))
+
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := compress(lf.latest(), name); err != nil {
+		return fmt.Errorf("Could not roll over %s: %s", lf.latest(), err)
+	}
+
+	return os.Remove(lf.latest())
+}
+
+// compress writes a gzip-compressed copy of the src file to dst.
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	gz.Name = filepath.Base(dst[:len(dst)-len(".gz")])
+
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,42 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is the format in which log entries are written.
+type Format string
+
+const (
+	// TextFormat writes entries as human-readable text, with colors when
+	// writing to a terminal.
+	TextFormat Format = "text"
+
+	// JSONFormat writes each entry as a JSON object on a single line.
+	JSONFormat Format = "json"
+)
+
+// OutputFormat is the format of log output, set by the --log-format flag.
+var OutputFormat = TextFormat
+
+// String returns the name of the format.
+func (format *Format) String() string {
+	return string(*format)
+}
+
+// Set parses the name of a format.
+func (format *Format) Set(value string) error {
+	switch f := Format(strings.ToLower(value)); f {
+	case TextFormat, JSONFormat:
+		*format = f
+		return nil
+	}
+
+	return fmt.Errorf("Invalid log format '%s'", value)
+}
+
+// Type returns the name of the format's type for usage messages.
+func (format *Format) Type() string {
+	return "format"
+}
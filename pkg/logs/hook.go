@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus hook that writes every log entry to a writer using its own
+// formatter, independently of the output and formatter of the logger.
+type Hook struct {
+	io.Writer
+	log.Formatter
+	mu sync.Mutex
+}
+
+// NewHook returns a hook that formats log entries with the formatter and
+// writes them to the writer.
+func NewHook(writer io.Writer, formatter log.Formatter) *Hook {
+	return &Hook{
+		Writer:    writer,
+		Formatter: formatter,
+	}
+}
+
+// Levels returns all log levels; entries are filtered by the level of the
+// logger before hooks are fired.
+func (hook *Hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire formats and writes a single log entry.
+func (hook *Hook) Fire(entry *log.Entry) error {
+	bytes, err := hook.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	_, err = hook.Write(bytes)
+	return err
+}
@@ -0,0 +1,133 @@
+package mc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the files that store banned players and IP addresses.
+const (
+	BannedPlayersFile = "banned-players.json"
+	BannedIPsFile     = "banned-ips.json"
+)
+
+// BanTimeFormat is the format of the creation and expiry times of bans.
+const BanTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// BanForever is the expiry time of bans that never expire.
+const BanForever = "forever"
+
+var (
+	bannedPlayers = &banList{path: BannedPlayersFile}
+	bannedIPs     = &banList{path: BannedIPsFile}
+)
+
+// Ban is an entry of a ban list, which bans either a player or an IP address
+// from joining the server.
+type Ban struct {
+	UUID    string `json:"uuid,omitempty"`
+	Name    string `json:"name,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+// Expired reports whether the expiry time of the ban has passed.
+func (ban *Ban) Expired() bool {
+	if ban.Expires == "" || ban.Expires == BanForever {
+		return false
+	}
+
+	expires, err := time.Parse(BanTimeFormat, ban.Expires)
+	return err == nil && time.Now().After(expires)
+}
+
+// banList is a list of banned players or IP addresses.
+type banList struct {
+	mu   sync.RWMutex
+	path string
+	bans []*Ban
+}
+
+// LoadBans loads the banned-players.json and banned-ips.json files, creating
+// them if they do not exist.
+func LoadBans() error {
+	for _, list := range []*banList{bannedPlayers, bannedIPs} {
+		if err := list.Load(); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+
+			if err := ioutil.WriteFile(list.path, []byte("[]"), 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// BannedPlayers returns the list of banned players.
+func BannedPlayers() *banList {
+	return bannedPlayers
+}
+
+// BannedIPs returns the list of banned IP addresses.
+func BannedIPs() *banList {
+	return bannedIPs
+}
+
+// Load replaces the bans with the contents of their file.
+func (list *banList) Load() error {
+	data, err := ioutil.ReadFile(list.path)
+	if err != nil {
+		return err
+	}
+
+	bans := make([]*Ban, 0)
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.bans = bans
+	logger.WithField("file", list.path).WithField("count", len(bans)).Debug("Loaded bans")
+
+	return nil
+}
+
+// Player returns the unexpired ban of the player, or nil if the player is not
+// banned.
+func (list *banList) Player(player *Player) *Ban {
+	return list.find(func(ban *Ban) bool {
+		return ban.UUID == player.UUID || strings.EqualFold(ban.Name, player.Name)
+	})
+}
+
+// IP returns the unexpired ban of the IP address, or nil if it is not banned.
+func (list *banList) IP(ip string) *Ban {
+	return list.find(func(ban *Ban) bool {
+		return ban.IP == ip
+	})
+}
+
+func (list *banList) find(match func(*Ban) bool) *Ban {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	for _, ban := range list.bans {
+		if match(ban) && !ban.Expired() {
+			return ban
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package mc
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -19,36 +20,127 @@ const (
 	// UnknownCommand means the command sent was not recognized.
 	UnknownCommand CommandType = iota
 
+	// CompleteCommand is a /complete command to list suggestions for partial
+	// command input.
+	CompleteCommand
+
+	// ListCommand is a /list command to list online players.
+	ListCommand
+
+	// RCONLockoutsCommand is an /rcon-lockouts command to list and clear
+	// lockouts of RCON clients after failed login attempts.
+	RCONLockoutsCommand
+
+	// ReloadCommand is a /reload command to reload the server configuration
+	// and player lists.
+	ReloadCommand
+
+	// SayCommand is a /say command to broadcast a message to all players.
+	SayCommand
+
 	// StopCommand is a /stop command to stop the server.
 	StopCommand
+
+	// TellrawCommand is a /tellraw command to send a JSON text component to
+	// players.
+	TellrawCommand
+
+	// WhitelistCommand is a /whitelist command to manage the whitelist.
+	WhitelistCommand
 )
 
 // Constants of command keywords.
 const (
-	StopCommandName = "stop"
+	CompleteCommandName     = "complete"
+	ListCommandName         = "list"
+	RCONLockoutsCommandName = "rcon-lockouts"
+	ReloadCommandName       = "reload"
+	SayCommandName          = "say"
+	StopCommandName         = "stop"
+	TellrawCommandName      = "tellraw"
+	WhitelistCommandName    = "whitelist"
 )
 
 // String maps a CommandType to its keyword.
 func (cmd CommandType) String() string {
 	switch cmd {
+	case CompleteCommand:
+		return CompleteCommandName
+	case ListCommand:
+		return ListCommandName
+	case RCONLockoutsCommand:
+		return RCONLockoutsCommandName
+	case ReloadCommand:
+		return ReloadCommandName
+	case SayCommand:
+		return SayCommandName
 	case StopCommand:
 		return StopCommandName
+	case TellrawCommand:
+		return TellrawCommandName
+	case WhitelistCommand:
+		return WhitelistCommandName
 	}
 
 	return ""
 }
 
+// MaxPermissionLevel is the permission level of the server console and RCON,
+// which may run any command.
+const MaxPermissionLevel = 4
+
+// PermissionLevel returns the permission level required to run commands of
+// the type.
+func (cmd CommandType) PermissionLevel() int {
+	switch cmd {
+	case SayCommand, TellrawCommand:
+		return 2
+	case WhitelistCommand:
+		return 3
+	case RCONLockoutsCommand, ReloadCommand, StopCommand:
+		return MaxPermissionLevel
+	}
+
+	return 0
+}
+
+// ErrEmptyCommand is returned when parsing command input that contains no
+// arguments.
+var ErrEmptyCommand = errors.New("Empty command")
+
 // Origin is the input (e.g. RCON, stdin) that sent a given command.
 type Origin interface {
 	io.Writer
 	Name() string
 }
 
+// PermissionOrigin is implemented by command origins whose permission level
+// may be less than MaxPermissionLevel.
+type PermissionOrigin interface {
+	Origin
+	PermissionLevel() int
+}
+
+// AllowlistOrigin is implemented by command origins that may be restricted to
+// a subset of the commands allowed by their permission level.
+type AllowlistOrigin interface {
+	Origin
+	AllowsCommand(CommandType) bool
+}
+
 // Command represents a command sent to the server.
 type Command struct {
 	CommandType
 	Origin
 	Args []string
+
+	// Input is the raw command input, if the command was parsed from it.
+	Input string
+
+	// Tokens are the positions of the command name and arguments in Input.
+	Tokens []Token
+
+	done chan struct{}
 }
 
 // NewCommand instantiates a Command from the origin and input string.
@@ -57,13 +149,141 @@ func NewCommand(origin Origin, args ...string) *Command {
 		CommandType: stringToCommandType(args[0]),
 		Origin:      origin,
 		Args:        args[1:],
+		done:        make(chan struct{}),
+	}
+}
+
+// Done returns a channel that closes when the command has finished executing
+// and all of its output has been written to its origin.
+func (command *Command) Done() <-chan struct{} {
+	return command.done
+}
+
+// Finish marks the command as finished executing. It must be called exactly
+// once by the server for each command that it receives.
+func (command *Command) Finish() {
+	close(command.done)
+}
+
+// ParseCommand tokenizes the input string and instantiates a Command from the
+// origin and resulting arguments. A leading slash is ignored.
+//
+// The input is tokenized one argument at a time while walking the command
+// tree, so that an argument that matches all remaining input, such as the
+// message of /say, is taken as it appears in the input rather than split into
+// tokens; it may contain unbalanced quotes or brackets.
+func ParseCommand(origin Origin, input string) (*Command, error) {
+	reader := NewStringReader(input)
+	tokens := make([]Token, 0)
+	node := CommandTree()
+
+	for reader.SkipWhitespace(); reader.CanRead(); reader.SkipWhitespace() {
+		if node != nil && node.takesRest(reader) {
+			tokens = append(tokens, Token{Value: reader.Remaining(), Start: reader.Cursor, End: len(input)})
+			break
+		}
+
+		token, err := reader.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tokens) == 0 && !token.Quoted && strings.HasPrefix(token.Value, "/") {
+			token.Value = token.Value[1:]
+		}
+
+		tokens = append(tokens, token)
+
+		if node != nil {
+			node = node.match(token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrEmptyCommand
+	}
+
+	args := make([]string, len(tokens))
+	for i, token := range tokens {
+		args[i] = token.Value
+	}
+
+	cmd := NewCommand(origin, args...)
+	cmd.Input = input
+	cmd.Tokens = tokens
+
+	return cmd, nil
+}
+
+// Rest returns the raw input following the command name and the given number
+// of arguments, or the remaining arguments joined by spaces if the command was
+// not parsed from input.
+func (command *Command) Rest(n int) string {
+	if command.Tokens == nil {
+		if n >= len(command.Args) {
+			return ""
+		}
+
+		return strings.Join(command.Args[n:], " ")
+	}
+
+	if n+1 >= len(command.Tokens) {
+		return ""
 	}
+
+	return command.Input[command.Tokens[n+1].Start:]
+}
+
+// PermissionLevel returns the permission level of the origin of the command.
+func (command *Command) PermissionLevel() int {
+	if po, ok := command.Origin.(PermissionOrigin); ok {
+		return po.PermissionLevel()
+	}
+
+	return MaxPermissionLevel
+}
+
+// Permitted reports whether the origin of the command is allowed to run it.
+func (command *Command) Permitted() bool {
+	if command.PermissionLevel() < command.CommandType.PermissionLevel() {
+		return false
+	}
+
+	if ao, ok := command.Origin.(AllowlistOrigin); ok {
+		return ao.AllowsCommand(command.CommandType)
+	}
+
+	return true
+}
+
+// Line returns the command input, or the command name and arguments if the
+// command was not parsed from input.
+func (command *Command) Line() string {
+	if command.Tokens != nil {
+		return command.Input
+	}
+
+	return strings.Join(append([]string{command.CommandType.String()}, command.Args...), " ")
 }
 
 func stringToCommandType(arg string) CommandType {
 	switch arg {
+	case CompleteCommandName:
+		return CompleteCommand
+	case ListCommandName:
+		return ListCommand
+	case RCONLockoutsCommandName:
+		return RCONLockoutsCommand
+	case ReloadCommandName:
+		return ReloadCommand
+	case SayCommandName:
+		return SayCommand
 	case StopCommandName:
 		return StopCommand
+	case TellrawCommandName:
+		return TellrawCommand
+	case WhitelistCommandName:
+		return WhitelistCommand
 	}
 
 	return UnknownCommand
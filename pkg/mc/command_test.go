@@ -0,0 +1,111 @@
+package mc
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testOrigin struct{}
+
+func (testOrigin) Write(p []byte) (int, error) { return len(p), nil }
+func (testOrigin) Name() string                { return "Test" }
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		input       string
+		commandType CommandType
+		args        []string
+		rest        []string
+	}{
+		{"list", ListCommand, []string{}, []string{""}},
+		{"/list", ListCommand, []string{}, []string{""}},
+		{`"/list"`, UnknownCommand, []string{}, []string{""}},
+		{"say hello  world ", SayCommand, []string{"hello  world "}, []string{"hello  world "}},
+		{"say 'sup", SayCommand, []string{"'sup"}, []string{"'sup"}},
+		{"say :[", SayCommand, []string{":["}, []string{":["}},
+		{`say "quoted" text`, SayCommand, []string{`"quoted" text`}, []string{`"quoted" text`}},
+		{"say", SayCommand, []string{}, []string{""}},
+		{"say   ", SayCommand, []string{}, []string{""}},
+		{"complete say 'sup", CompleteCommand, []string{"say 'sup"}, []string{"say 'sup"}},
+		{`tellraw @a[name="a b"] {"text": "hi ]"}`, TellrawCommand,
+			[]string{`@a[name="a b"]`, `{"text": "hi ]"}`},
+			[]string{`@a[name="a b"] {"text": "hi ]"}`, `{"text": "hi ]"}`}},
+		{`tellraw Steve "unclosed`, TellrawCommand, []string{"Steve", `"unclosed`}, []string{`Steve "unclosed`, `"unclosed`}},
+		{`tellraw "Steve" [`, TellrawCommand, []string{"Steve", "["}, []string{`"Steve" [`, "["}},
+		{"whitelist add 'A B'", WhitelistCommand, []string{"add", "A B"}, []string{"add 'A B'", "'A B'"}},
+		{"rcon-lockouts clear 127.0.0.1", RCONLockoutsCommand, []string{"clear", "127.0.0.1"}, []string{"clear 127.0.0.1", "127.0.0.1"}},
+	}
+
+	for _, test := range tests {
+		cmd, err := ParseCommand(testOrigin{}, test.input)
+		if err != nil {
+			t.Errorf("ParseCommand(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if cmd.CommandType != test.commandType || !reflect.DeepEqual(cmd.Args, test.args) {
+			t.Errorf("ParseCommand(%q) = %s %q, expected %s %q", test.input, cmd.CommandType, cmd.Args, test.commandType, test.args)
+		}
+
+		for n, rest := range test.rest {
+			if r := cmd.Rest(n); r != rest {
+				t.Errorf("ParseCommand(%q).Rest(%d) = %q, expected %q", test.input, n, r, rest)
+			}
+		}
+
+		if cmd.Line() != test.input {
+			t.Errorf("ParseCommand(%q).Line() = %q", test.input, cmd.Line())
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		cursor  int
+	}{
+		{`"list`, "Unclosed quoted string", 5},
+		{`tellraw @a[name="x] hi`, "Unclosed quoted string", 22},
+		{`tellraw @a[ {}`, "Expected ']'", 14},
+		{`whitelist add 'A B`, "Unclosed quoted string", 18},
+		{`unknown 'x`, "Unclosed quoted string", 10},
+	}
+
+	for _, test := range tests {
+		_, err := ParseCommand(testOrigin{}, test.input)
+
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseCommand(%q) returned %v, expected a syntax error", test.input, err)
+			continue
+		}
+
+		if serr.Message != test.message || serr.Cursor != test.cursor {
+			t.Errorf("ParseCommand(%q) returned %q at %d, expected %q at %d",
+				test.input, serr.Message, serr.Cursor, test.message, test.cursor)
+		}
+	}
+
+	for _, input := range []string{"", "  ", "\t"} {
+		if _, err := ParseCommand(testOrigin{}, input); err != ErrEmptyCommand {
+			t.Errorf("ParseCommand(%q) returned %v, expected %v", input, err, ErrEmptyCommand)
+		}
+	}
+}
+
+func TestCommandRestUnparsed(t *testing.T) {
+	cmd := NewCommand(testOrigin{}, "tellraw", "@a", `{"text":`, `"hi"}`)
+
+	if rest := cmd.Rest(1); rest != `{"text": "hi"}` {
+		t.Errorf("Rest(1) = %q", rest)
+	}
+
+	if rest := cmd.Rest(3); rest != "" {
+		t.Errorf("Rest(3) = %q", rest)
+	}
+
+	if line := cmd.Line(); line != `tellraw @a {"text": "hi"}` {
+		t.Errorf("Line() = %q", line)
+	}
+}
@@ -0,0 +1,41 @@
+package mc
+
+import "fmt"
+
+// Difficulty is the difficulty of the world.
+type Difficulty int
+
+// Difficulties, with values matching their protocol IDs.
+const (
+	Peaceful Difficulty = iota
+	Easy
+	Normal
+	Hard
+)
+
+// String maps a Difficulty to its name.
+func (d Difficulty) String() string {
+	switch d {
+	case Peaceful:
+		return "peaceful"
+	case Easy:
+		return "easy"
+	case Normal:
+		return "normal"
+	case Hard:
+		return "hard"
+	}
+
+	return ""
+}
+
+// ParseDifficulty returns the Difficulty with the given name.
+func ParseDifficulty(name string) (Difficulty, error) {
+	for _, d := range []Difficulty{Peaceful, Easy, Normal, Hard} {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+
+	return Easy, fmt.Errorf("Invalid difficulty '%s'", name)
+}
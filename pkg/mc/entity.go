@@ -0,0 +1,118 @@
+package mc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PlayerEntityType is the namespaced entity type of players.
+const PlayerEntityType = "minecraft:player"
+
+// GameMode is the game mode of a player.
+type GameMode int
+
+// Game modes, with values matching their protocol IDs.
+const (
+	Survival GameMode = iota
+	Creative
+	Adventure
+	Spectator
+)
+
+// String maps a GameMode to its name.
+func (gm GameMode) String() string {
+	switch gm {
+	case Survival:
+		return "survival"
+	case Creative:
+		return "creative"
+	case Adventure:
+		return "adventure"
+	case Spectator:
+		return "spectator"
+	}
+
+	return ""
+}
+
+// ParseGameMode returns the GameMode with the given name.
+func ParseGameMode(name string) (GameMode, error) {
+	for _, gm := range []GameMode{Survival, Creative, Adventure, Spectator} {
+		if gm.String() == name {
+			return gm, nil
+		}
+	}
+
+	return Survival, fmt.Errorf("Invalid game mode '%s'", name)
+}
+
+// Position is a location in the world.
+type Position struct {
+	X, Y, Z float64
+}
+
+// Distance returns the distance between two positions.
+func (pos Position) Distance(other Position) float64 {
+	dx, dy, dz := pos.X-other.X, pos.Y-other.Y, pos.Z-other.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// Entity is a snapshot of the state of an entity in the world, used when
+// selecting the targets of a command.
+type Entity struct {
+	UUID     string
+	Name     string
+	Type     string
+	Position Position
+	GameMode GameMode
+	Level    int
+	Tags     []string
+}
+
+// NewPlayerEntity returns an Entity for the given player at the given
+// position.
+func NewPlayerEntity(player *Player, pos Position, gm GameMode) *Entity {
+	return &Entity{
+		UUID:     player.UUID,
+		Name:     player.Name,
+		Type:     PlayerEntityType,
+		Position: pos,
+		GameMode: gm,
+		Tags:     make([]string, 0),
+	}
+}
+
+// IsPlayer reports whether the entity is a player.
+func (entity *Entity) IsPlayer() bool {
+	return entity.Type == PlayerEntityType
+}
+
+// HasTag reports whether the entity has the given tag.
+func (entity *Entity) HasTag(tag string) bool {
+	for _, t := range entity.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Player returns the player identified by the entity.
+func (entity *Entity) Player() *Player {
+	return &Player{
+		UUID: entity.UUID,
+		Name: entity.Name,
+	}
+}
+
+// NamespacedID adds the default "minecraft:" namespace to an identifier that
+// does not have one.
+func NamespacedID(id string) string {
+	if strings.Contains(id, ":") {
+		return id
+	}
+
+	return "minecraft:" + id
+}
@@ -7,7 +7,6 @@ import (
 	"text/template"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
@@ -72,7 +71,7 @@ func (data *eulaData) Timestamp() string {
 }
 
 func writeEULA(wr io.Writer) error {
-	log.Debug("Writing EULA file")
+	logger.Debug("Writing EULA file")
 
 	tmpl, err := template.New("eula").Parse(eulaTemplate)
 	if err != nil {
@@ -0,0 +1,88 @@
+package mc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OpsFile is the name of the file that stores server operators.
+const OpsFile = "ops.json"
+
+var ops = &opList{path: OpsFile}
+
+// Op is a server operator, who may join the server even when the whitelist
+// is enabled and they are not on it.
+type Op struct {
+	Player
+	Level               int  `json:"level"`
+	BypassesPlayerLimit bool `json:"bypassesPlayerLimit"`
+}
+
+// opList is the list of server operators.
+type opList struct {
+	mu   sync.RWMutex
+	path string
+	ops  []*Op
+}
+
+// LoadOps loads the ops.json file, creating it if it does not exist.
+func LoadOps() error {
+	if err := ops.Load(); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+
+		return ioutil.WriteFile(ops.path, []byte("[]"), 0644)
+	}
+
+	return nil
+}
+
+// Ops returns the server operators.
+func Ops() *opList {
+	return ops
+}
+
+// Load replaces the operators with the contents of their file.
+func (list *opList) Load() error {
+	data, err := ioutil.ReadFile(list.path)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*Op, 0)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.ops = entries
+	logger.WithField("count", len(entries)).Debug("Loaded operators")
+
+	return nil
+}
+
+// Contains reports whether the player is an operator.
+func (list *opList) Contains(player *Player) bool {
+	return list.Level(player) > 0
+}
+
+// Level returns the permission level of the player, which is zero if the
+// player is not an operator.
+func (list *opList) Level(player *Player) int {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	for _, op := range list.ops {
+		if op.UUID == player.UUID || strings.EqualFold(op.Name, player.Name) {
+			return op.Level
+		}
+	}
+
+	return 0
+}
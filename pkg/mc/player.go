@@ -0,0 +1,36 @@
+package mc
+
+import (
+	"crypto/md5"
+	"fmt"
+)
+
+// Player identifies a player by UUID and name.
+type Player struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// NewOfflinePlayer returns a Player with the name-based UUID that is assigned
+// to players on servers running in offline mode.
+func NewOfflinePlayer(name string) *Player {
+	return &Player{
+		UUID: OfflineUUID(name),
+		Name: name,
+	}
+}
+
+// OfflineUUID generates the version 3 UUID of the given player name, which is
+// derived from the MD5 hash of "OfflinePlayer:<name>".
+func OfflineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// String returns the name of the player.
+func (player *Player) String() string {
+	return player.Name
+}
@@ -2,80 +2,322 @@ package mc
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"sync"
 
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+var logger = logs.Component("mc")
+
 // Properties default values
 const (
-	EnableRCON = false
-	ServerIP   = ""
-	ServerPort = 25565
-	RCONPort   = 25575
+	AllowFlight                 = false
+	AllowNether                 = true
+	BroadcastConsoleToOps       = true
+	BroadcastLAN                = false
+	BroadcastRCONToOps          = true
+	DefaultDifficulty           = Easy
+	DefaultGameMode             = Survival
+	EnableCommandBlock          = false
+	EnableQuery                 = false
+	EnableRCON                  = false
+	EnforceWhitelist            = false
+	ForceGameMode               = false
+	FunctionPermissionLevel     = 2
+	GenerateStructures          = true
+	GeneratorSettings           = ""
+	Hardcore                    = false
+	LevelName                   = "world"
+	LevelSeed                   = ""
+	LevelType                   = "default"
+	MaxBuildHeight              = 256
+	MaxPlayers                  = 20
+	MaxTickTime                 = 60000
+	MaxWorldSize                = 29999984
+	MOTD                        = "A Minecraft Server"
+	NetworkCompressionThreshold = 256
+	OnlineMode                  = true
+	OpPermissionLevel           = 4
+	PlayerIdleTimeout           = 0
+	PreventProxyConnections     = false
+	PVP                         = true
+	ResourcePack                = ""
+	ResourcePackSHA1            = ""
+	ServerIP                    = ""
+	ServerPort                  = 25565
+	SnooperEnabled              = true
+	SpawnAnimals                = true
+	SpawnMonsters               = true
+	SpawnNPCs                   = true
+	SpawnProtection             = 16
+	UseNativeTransport          = true
+	ViewDistance                = 10
+	WhiteList                   = false
+
+	QueryPort = 25565
+
+	RCONPort             = 25575
+	RCONMaxLoginAttempts = 5
+	RCONLockoutDuration  = 300
 )
 
-var props *properties
+var (
+	props   *properties
+	propsMu sync.RWMutex
+
+	// boundFlags are the command line flags bound to properties, which are
+	// bound again to every reloaded configuration.
+	boundFlags = make(map[string]*pflag.Flag)
+)
 
 type properties struct {
 	*viper.Viper
-	EnableRCON bool   `mapstructure:"enable-rcon"`
-	ServerIP   string `mapstructure:"server-ip"`
-	ServerPort int    `mapstructure:"server-port"`
-	RCON       struct {
-		Password string
-		Port     int
+	AllowFlight                 bool       `mapstructure:"allow-flight"`
+	AllowNether                 bool       `mapstructure:"allow-nether"`
+	BroadcastConsoleToOps       bool       `mapstructure:"broadcast-console-to-ops"`
+	BroadcastLAN                bool       `mapstructure:"broadcast-lan"`
+	BroadcastRCONToOps          bool       `mapstructure:"broadcast-rcon-to-ops"`
+	Difficulty                  Difficulty `mapstructure:"-"`
+	EnableCommandBlock          bool       `mapstructure:"enable-command-block"`
+	EnableQuery                 bool       `mapstructure:"enable-query"`
+	EnableRCON                  bool       `mapstructure:"enable-rcon"`
+	EnforceWhitelist            bool       `mapstructure:"enforce-whitelist"`
+	ForceGameMode               bool       `mapstructure:"force-gamemode"`
+	FunctionPermissionLevel     int        `mapstructure:"function-permission-level"`
+	GameMode                    GameMode   `mapstructure:"-"`
+	GenerateStructures          bool       `mapstructure:"generate-structures"`
+	GeneratorSettings           string     `mapstructure:"generator-settings"`
+	Hardcore                    bool       `mapstructure:"hardcore"`
+	LevelName                   string     `mapstructure:"level-name"`
+	LevelSeed                   string     `mapstructure:"level-seed"`
+	LevelType                   string     `mapstructure:"level-type"`
+	MaxBuildHeight              int        `mapstructure:"max-build-height"`
+	MaxPlayers                  int        `mapstructure:"max-players"`
+	MaxTickTime                 int        `mapstructure:"max-tick-time"`
+	MaxWorldSize                int        `mapstructure:"max-world-size"`
+	MOTD                        string     `mapstructure:"motd"`
+	NetworkCompressionThreshold int        `mapstructure:"network-compression-threshold"`
+	OnlineMode                  bool       `mapstructure:"online-mode"`
+	OpPermissionLevel           int        `mapstructure:"op-permission-level"`
+	PlayerIdleTimeout           int        `mapstructure:"player-idle-timeout"`
+	PreventProxyConnections     bool       `mapstructure:"prevent-proxy-connections"`
+	PVP                         bool       `mapstructure:"pvp"`
+	ResourcePack                string     `mapstructure:"resource-pack"`
+	ResourcePackSHA1            string     `mapstructure:"resource-pack-sha1"`
+	ServerIP                    string     `mapstructure:"server-ip"`
+	ServerPort                  int        `mapstructure:"server-port"`
+	SnooperEnabled              bool       `mapstructure:"snooper-enabled"`
+	SpawnAnimals                bool       `mapstructure:"spawn-animals"`
+	SpawnMonsters               bool       `mapstructure:"spawn-monsters"`
+	SpawnNPCs                   bool       `mapstructure:"spawn-npcs"`
+	SpawnProtection             int        `mapstructure:"spawn-protection"`
+	UseNativeTransport          bool       `mapstructure:"use-native-transport"`
+	ViewDistance                int        `mapstructure:"view-distance"`
+	WhiteList                   bool       `mapstructure:"white-list"`
+	Query                       struct {
+		Port int
+	}
+	RCON struct {
+		Password         string
+		Port             int
+		MaxLoginAttempts int `mapstructure:"max-login-attempts"`
+		LockoutDuration  int `mapstructure:"lockout-duration"`
+		WebSocket        struct {
+			Port           int
+			AllowedOrigins string `mapstructure:"allowed-origins"`
+		}
+		TLS struct {
+			CertFile     string `mapstructure:"cert-file"`
+			KeyFile      string `mapstructure:"key-file"`
+			ClientCAFile string `mapstructure:"client-ca-file"`
+			CertLogin    bool   `mapstructure:"cert-login"`
+		}
 	}
 }
 
 func init() {
-	props = &properties{}
-	props.Viper = viper.New()
+	props = newProperties()
+}
 
-	props.SetConfigName("server")
-	props.AddConfigPath(".")
+// newProperties returns an empty configuration with the default values of
+// all properties, which reads the server.properties file when loaded.
+func newProperties() *properties {
+	p := &properties{Viper: viper.New()}
 
-	props.SetDefault("enable-rcon", EnableRCON)
-	props.SetDefault("server-ip", ServerIP)
-	props.SetDefault("server-port", ServerPort)
-	props.SetDefault("rcon.password", "")
-	props.SetDefault("rcon.port", RCONPort)
+	p.SetConfigName("server")
+	p.AddConfigPath(".")
+
+	for _, spec := range propertySpecs {
+		p.SetDefault(spec.key, spec.def)
+	}
+
+	return p
 }
 
-// LoadProperties loads the server.properties file.
+// LoadProperties loads and validates the server.properties file.
 func LoadProperties() error {
-	if err := props.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return err
-		}
+	return props.load()
+}
 
-		if werr := props.SafeWriteConfigAs("server.properties"); werr != nil {
-			return werr
+// ReloadProperties loads and validates the server.properties file into a new
+// configuration, which replaces the current one only if it is valid, and
+// returns the properties whose values changed.
+func ReloadProperties() (*PropertyChanges, error) {
+	p := newProperties()
+
+	for key, flag := range boundFlags {
+		if err := p.Viper.BindPFlag(key, flag); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := props.WriteConfig(); err != nil {
-		return err
+	if err := p.load(); err != nil {
+		return nil, err
 	}
 
-	return props.Unmarshal(props)
+	propsMu.Lock()
+	old := props
+	props = p
+	propsMu.Unlock()
+
+	return diffProperties(old, p), nil
 }
 
 // Properties returns the current server configuration.
 func Properties() *properties {
+	propsMu.RLock()
+	defer propsMu.RUnlock()
+
 	return props
 }
 
-// RCONAddr returns the address and port to which the RCON listener is bound.
+// BindPFlag binds a property to a command line flag, whose value overrides the
+// server.properties file if it is set. The binding is kept when the file is
+// reloaded.
+func (p *properties) BindPFlag(key string, flag *pflag.Flag) error {
+	boundFlags[key] = flag
+	return p.Viper.BindPFlag(key, flag)
+}
+
+func (p *properties) load() error {
+	if err := p.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return err
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.save(nil); err != nil {
+		return err
+	}
+
+	if err := p.Unmarshal(p); err != nil {
+		return err
+	}
+
+	p.Difficulty, _ = ParseDifficulty(p.GetString("difficulty"))
+	p.GameMode, _ = ParseGameMode(p.GetString("gamemode"))
+
+	return nil
+}
+
+// QueryAddr returns the address and port to which the Query listener is bound,
+// or an empty string if Query is disabled.
+func (p *properties) QueryAddr() string {
+	if p.EnableQuery {
+		return fmt.Sprintf("%s:%d", p.ServerIP, p.Query.Port)
+	}
+
+	return ""
+}
+
+// RCONAddr returns the address and port to which the RCON listener is bound,
+// or an empty string if RCON is disabled.
 func (p *properties) RCONAddr() string {
-	if p.EnableRCON && p.RCON.Password != "" {
+	if p.EnableRCON {
 		return fmt.Sprintf("%s:%d", p.ServerIP, p.RCON.Port)
 	}
 
 	return ""
 }
 
+// RCONWebSocketAddr returns the address and port to which the WebSocket RCON
+// listener is bound, or an empty string if RCON or its WebSocket endpoint is
+// disabled.
+func (p *properties) RCONWebSocketAddr() string {
+	if p.EnableRCON && p.RCON.WebSocket.Port != 0 {
+		return fmt.Sprintf("%s:%d", p.ServerIP, p.RCON.WebSocket.Port)
+	}
+
+	return ""
+}
+
+// RCONTLS reports whether RCON connections are wrapped in TLS, which requires
+// both a certificate and a key file.
+func (p *properties) RCONTLS() bool {
+	return p.RCON.TLS.CertFile != "" && p.RCON.TLS.KeyFile != ""
+}
+
+// RCONCertLogin reports whether RCON clients may log in with a verified
+// client certificate instead of a password.
+func (p *properties) RCONCertLogin() bool {
+	return p.RCONTLS() && p.RCON.TLS.ClientCAFile != "" && p.RCON.TLS.CertLogin
+}
+
 // ServerAddr returns the address and port to which the Minecraft listener is
 // bound.
 func (p *properties) ServerAddr() string {
 	return fmt.Sprintf("%s:%d", p.ServerIP, p.ServerPort)
 }
+
+// SetWhiteList enables or disables the whitelist and saves the change to the
+// server.properties file.
+func (p *properties) SetWhiteList(enabled bool) error {
+	p.Set("white-list", enabled)
+	p.WhiteList = enabled
+
+	return p.save(map[string]string{"white-list": strconv.FormatBool(enabled)})
+}
+
+// save adds any missing properties to the server.properties file with their
+// default values and replaces the values of the updated properties, leaving
+// every other line of the file as it was. The file is only written if it
+// changed.
+func (p *properties) save(updates map[string]string) error {
+	path := p.ConfigFileUsed()
+	if path == "" {
+		path = PropertiesFile
+	}
+
+	file, err := readPropertiesFile(path)
+	if os.IsNotExist(err) {
+		file = newPropertiesFile()
+	} else if err != nil {
+		return err
+	}
+
+	changed := false
+
+	for _, spec := range propertySpecs {
+		if _, ok := file.Get(spec.key); !ok {
+			changed = file.Set(spec.key, fmt.Sprint(spec.def)) || changed
+		}
+	}
+
+	for key, value := range updates {
+		changed = file.Set(key, value) || changed
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return file.WriteFile(path)
+}
@@ -0,0 +1,225 @@
+package mc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// propertyKind is the type of the value of a property.
+type propertyKind int
+
+const (
+	stringProperty propertyKind = iota
+	boolProperty
+	intProperty
+	enumProperty
+)
+
+// propertyApply is how a change to the value of a property is applied while
+// the server is running.
+type propertyApply int
+
+const (
+	// applyLive properties are read whenever they are used, so changes take
+	// effect immediately.
+	applyLive propertyApply = iota
+
+	// applyRebind properties are the addresses of network listeners, and take
+	// effect once the listeners are rebound.
+	applyRebind
+
+	// applyRestart properties are only read when the server starts, or are not
+	// used by the server yet.
+	applyRestart
+)
+
+// propertySpec describes a key of the server.properties file, its default
+// value, and its valid values.
+type propertySpec struct {
+	key   string
+	kind  propertyKind
+	def   interface{}
+	apply propertyApply
+
+	// min and max are the range of integer properties.
+	min int
+	max int
+
+	// values are the valid values of enum properties. The index of each value
+	// is accepted in its place, and replaced with the value.
+	values []string
+}
+
+func stringSpec(key string, def string) propertySpec {
+	return propertySpec{key: key, kind: stringProperty, def: def}
+}
+
+func boolSpec(key string, def bool) propertySpec {
+	return propertySpec{key: key, kind: boolProperty, def: def}
+}
+
+func intSpec(key string, def int, min int, max int) propertySpec {
+	return propertySpec{key: key, kind: intProperty, def: def, min: min, max: max}
+}
+
+func enumSpec(key string, def string, values ...string) propertySpec {
+	return propertySpec{key: key, kind: enumProperty, def: def, values: values}
+}
+
+// rebind marks a property whose changes take effect once the network
+// listeners are rebound.
+func (spec propertySpec) rebind() propertySpec {
+	spec.apply = applyRebind
+	return spec
+}
+
+// restart marks a property whose changes only take effect after a restart.
+func (spec propertySpec) restart() propertySpec {
+	spec.apply = applyRestart
+	return spec
+}
+
+// propertySpecs are all the properties of the server: those of the vanilla
+// server, followed by Gophermine's own.
+var propertySpecs = []propertySpec{
+	boolSpec("allow-flight", AllowFlight).restart(),
+	boolSpec("allow-nether", AllowNether).restart(),
+	boolSpec("broadcast-console-to-ops", BroadcastConsoleToOps).restart(),
+	boolSpec("broadcast-rcon-to-ops", BroadcastRCONToOps).restart(),
+	enumSpec("difficulty", DefaultDifficulty.String(), Peaceful.String(), Easy.String(), Normal.String(), Hard.String()).restart(),
+	boolSpec("enable-command-block", EnableCommandBlock).restart(),
+	boolSpec("enable-query", EnableQuery).rebind(),
+	boolSpec("enable-rcon", EnableRCON).rebind(),
+	boolSpec("enforce-whitelist", EnforceWhitelist),
+	boolSpec("force-gamemode", ForceGameMode).restart(),
+	intSpec("function-permission-level", FunctionPermissionLevel, 1, MaxPermissionLevel).restart(),
+	enumSpec("gamemode", DefaultGameMode.String(), Survival.String(), Creative.String(), Adventure.String(), Spectator.String()),
+	boolSpec("generate-structures", GenerateStructures).restart(),
+	stringSpec("generator-settings", GeneratorSettings).restart(),
+	boolSpec("hardcore", Hardcore).restart(),
+	stringSpec("level-name", LevelName).restart(),
+	stringSpec("level-seed", LevelSeed).restart(),
+	enumSpec("level-type", LevelType, "default", "flat", "largebiomes", "amplified", "buffet").restart(),
+	intSpec("max-build-height", MaxBuildHeight, 64, 256).restart(),
+	intSpec("max-players", MaxPlayers, 0, math.MaxInt32),
+	intSpec("max-tick-time", MaxTickTime, -1, math.MaxInt32).restart(),
+	intSpec("max-world-size", MaxWorldSize, 1, 29999984).restart(),
+	stringSpec("motd", MOTD),
+	intSpec("network-compression-threshold", NetworkCompressionThreshold, -1, math.MaxInt32).restart(),
+	boolSpec("online-mode", OnlineMode).restart(),
+	intSpec("op-permission-level", OpPermissionLevel, 1, MaxPermissionLevel).restart(),
+	intSpec("player-idle-timeout", PlayerIdleTimeout, 0, math.MaxInt32).restart(),
+	boolSpec("prevent-proxy-connections", PreventProxyConnections).restart(),
+	boolSpec("pvp", PVP).restart(),
+	intSpec("query.port", QueryPort, 1, math.MaxUint16).rebind(),
+	stringSpec("rcon.password", ""),
+	intSpec("rcon.port", RCONPort, 1, math.MaxUint16).rebind(),
+	stringSpec("resource-pack", ResourcePack).restart(),
+	stringSpec("resource-pack-sha1", ResourcePackSHA1).restart(),
+	stringSpec("server-ip", ServerIP).rebind(),
+	intSpec("server-port", ServerPort, 1, math.MaxUint16).rebind(),
+	boolSpec("snooper-enabled", SnooperEnabled).restart(),
+	boolSpec("spawn-animals", SpawnAnimals).restart(),
+	boolSpec("spawn-monsters", SpawnMonsters).restart(),
+	boolSpec("spawn-npcs", SpawnNPCs).restart(),
+	intSpec("spawn-protection", SpawnProtection, 0, math.MaxInt32).restart(),
+	boolSpec("use-native-transport", UseNativeTransport).restart(),
+	intSpec("view-distance", ViewDistance, 3, 32).restart(),
+	boolSpec("white-list", WhiteList),
+
+	boolSpec("broadcast-lan", BroadcastLAN).rebind(),
+	intSpec("rcon.max-login-attempts", RCONMaxLoginAttempts, 0, math.MaxInt32),
+	intSpec("rcon.lockout-duration", RCONLockoutDuration, 0, math.MaxInt32),
+	intSpec("rcon.websocket.port", 0, 0, math.MaxUint16).rebind(),
+	stringSpec("rcon.websocket.allowed-origins", "").restart(),
+	stringSpec("rcon.tls.cert-file", "").restart(),
+	stringSpec("rcon.tls.key-file", "").restart(),
+	stringSpec("rcon.tls.client-ca-file", "").restart(),
+	boolSpec("rcon.tls.cert-login", false).restart(),
+}
+
+// PropertyChanges are the properties whose values changed when the
+// server.properties file was reloaded, grouped by how the changes are applied.
+type PropertyChanges struct {
+	// Live are the properties whose changes have taken effect.
+	Live []string
+
+	// Rebind are the properties whose changes take effect once the network
+	// listeners are rebound.
+	Rebind []string
+
+	// Restart are the properties whose changes only take effect after the
+	// server is restarted.
+	Restart []string
+}
+
+// diffProperties returns the properties whose values differ between two
+// configurations.
+func diffProperties(old *properties, p *properties) *PropertyChanges {
+	changes := &PropertyChanges{}
+
+	for _, spec := range propertySpecs {
+		if old.GetString(spec.key) == p.GetString(spec.key) {
+			continue
+		}
+
+		switch spec.apply {
+		case applyLive:
+			changes.Live = append(changes.Live, spec.key)
+		case applyRebind:
+			changes.Rebind = append(changes.Rebind, spec.key)
+		case applyRestart:
+			changes.Restart = append(changes.Restart, spec.key)
+		}
+	}
+
+	return changes
+}
+
+// Validate checks the value of every known property, and returns an error
+// naming the first property with an invalid value and its valid values. Enum
+// properties given by their numeric IDs are replaced with their names.
+func (p *properties) Validate() error {
+	for _, spec := range propertySpecs {
+		raw := strings.TrimSpace(p.GetString(spec.key))
+
+		value, err := spec.validate(raw)
+		if err != nil {
+			return err
+		}
+
+		if value != raw {
+			p.Set(spec.key, value)
+		}
+	}
+
+	return nil
+}
+
+// validate returns the normalized value of the property, or an error if the
+// value is invalid.
+func (spec propertySpec) validate(value string) (string, error) {
+	switch spec.kind {
+	case boolProperty:
+		if value != "true" && value != "false" {
+			return "", fmt.Errorf("Invalid value %q for %s: must be true or false", value, spec.key)
+		}
+	case intProperty:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < int64(spec.min) || n > int64(spec.max) {
+			return "", fmt.Errorf("Invalid value %q for %s: must be an integer from %d to %d", value, spec.key, spec.min, spec.max)
+		}
+	case enumProperty:
+		for i, v := range spec.values {
+			if strings.EqualFold(value, v) || value == strconv.Itoa(i) {
+				return v, nil
+			}
+		}
+
+		return "", fmt.Errorf("Invalid value %q for %s: must be one of %s", value, spec.key, strings.Join(spec.values, ", "))
+	}
+
+	return value, nil
+}
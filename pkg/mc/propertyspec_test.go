@@ -0,0 +1,53 @@
+package mc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffProperties(t *testing.T) {
+	old := newProperties()
+	p := newProperties()
+
+	p.Set("motd", "Changed")
+	p.Set("max-players", 5)
+	p.Set("server-port", 25566)
+	p.Set("pvp", false)
+	p.Set("online-mode", false)
+	p.Set("level-seed", "42")
+	p.Set("view-distance", ViewDistance)
+
+	changes := diffProperties(old, p)
+
+	expected := &PropertyChanges{
+		Live:    []string{"max-players", "motd"},
+		Rebind:  []string{"server-port"},
+		Restart: []string{"level-seed", "online-mode", "pvp"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("diffProperties() = %+v, expected %+v", changes, expected)
+	}
+}
+
+// liveProperties are the properties that the server reads whenever it uses
+// them. Every other property must be applied by rebinding or restarting, so
+// that reloading does not claim to have applied changes it ignores.
+var liveProperties = map[string]bool{
+	"enforce-whitelist":       true,
+	"gamemode":                true,
+	"max-players":             true,
+	"motd":                    true,
+	"rcon.lockout-duration":   true,
+	"rcon.max-login-attempts": true,
+	"rcon.password":           true,
+	"white-list":              true,
+}
+
+func TestLiveProperties(t *testing.T) {
+	for _, spec := range propertySpecs {
+		if live := spec.apply == applyLive; live != liveProperties[spec.key] {
+			t.Errorf("%s is applied live: %v, expected %v", spec.key, live, liveProperties[spec.key])
+		}
+	}
+}
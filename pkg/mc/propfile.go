@@ -0,0 +1,348 @@
+package mc
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PropertiesFile is the name of the server configuration file.
+const PropertiesFile = "server.properties"
+
+// propertiesHeader is the comment at the top of a new server.properties file.
+const propertiesHeader = "#Minecraft server properties"
+
+// propertiesDateFormat is the format of the date comment below the header,
+// matching java.util.Date.
+const propertiesDateFormat = "Mon Jan 02 15:04:05 MST 2006"
+
+// propertiesFile is the contents of a .properties file, kept line by line so
+// that it can be written back exactly as it was read, apart from the entries
+// that are changed or added.
+type propertiesFile struct {
+	lines   []*propertiesLine
+	newline string
+}
+
+// propertiesLine is a logical line of a .properties file: an entry, which may
+// span several physical lines, or a comment or blank line.
+type propertiesLine struct {
+	text  string
+	key   string
+	value string
+	entry bool
+}
+
+// newPropertiesFile returns an empty properties file with the vanilla header.
+func newPropertiesFile() *propertiesFile {
+	file := &propertiesFile{newline: "\n"}
+	file.lines = []*propertiesLine{
+		{text: propertiesHeader + "\n"},
+		{text: "#" + time.Now().Format(propertiesDateFormat) + "\n"},
+	}
+
+	return file
+}
+
+// readPropertiesFile reads and parses the properties file at the path.
+func readPropertiesFile(path string) (*propertiesFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProperties(data), nil
+}
+
+// parseProperties splits the contents of a properties file into logical lines
+// and decodes the key and value of each entry.
+func parseProperties(data []byte) *propertiesFile {
+	file := &propertiesFile{newline: "\n"}
+	if bytes.Contains(data, []byte("\r\n")) {
+		file.newline = "\r\n"
+	}
+
+	physical := strings.SplitAfter(string(data), "\n")
+	if physical[len(physical)-1] == "" {
+		physical = physical[:len(physical)-1]
+	}
+
+	for i := 0; i < len(physical); i++ {
+		text := physical[i]
+		content := strings.TrimLeft(trimNewline(text), " \t\f")
+
+		if content == "" || content[0] == '#' || content[0] == '!' {
+			file.lines = append(file.lines, &propertiesLine{text: text})
+			continue
+		}
+
+		// Join continuation lines, which follow a line ending in an odd number
+		// of backslashes, dropping the backslash and leading whitespace.
+		for continues(content) && i+1 < len(physical) {
+			i++
+			text += physical[i]
+			content = content[:len(content)-1] + strings.TrimLeft(trimNewline(physical[i]), " \t\f")
+		}
+
+		key, value := splitEntry(content)
+		file.lines = append(file.lines, &propertiesLine{text: text, key: key, value: value, entry: true})
+	}
+
+	return file
+}
+
+// Get returns the value of the last entry with the key.
+func (file *propertiesFile) Get(key string) (string, bool) {
+	for i := len(file.lines) - 1; i >= 0; i-- {
+		if line := file.lines[i]; line.entry && line.key == key {
+			return line.value, true
+		}
+	}
+
+	return "", false
+}
+
+// Set replaces the entry with the key if its value differs, or appends a new
+// entry if there is none, and reports whether the file changed.
+func (file *propertiesFile) Set(key string, value string) bool {
+	for i := len(file.lines) - 1; i >= 0; i-- {
+		line := file.lines[i]
+		if !line.entry || line.key != key {
+			continue
+		}
+
+		if line.value == value {
+			return false
+		}
+
+		line.value = value
+		line.text = file.format(key, value)
+
+		return true
+	}
+
+	if n := len(file.lines); n > 0 && !strings.HasSuffix(file.lines[n-1].text, "\n") {
+		file.lines[n-1].text += file.newline
+	}
+
+	file.lines = append(file.lines, &propertiesLine{text: file.format(key, value), key: key, value: value, entry: true})
+	return true
+}
+
+// Bytes returns the contents of the file.
+func (file *propertiesFile) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	for _, line := range file.lines {
+		buf.WriteString(line.text)
+	}
+
+	return buf.Bytes()
+}
+
+// WriteFile atomically replaces the file at the path with the contents of the
+// properties file, by writing them to a temporary file in the same directory
+// and renaming it over the original.
+func (file *propertiesFile) WriteFile(path string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(file.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// format returns an entry line in the vanilla format, with the key and value
+// escaped the same way as java.util.Properties.
+func (file *propertiesFile) format(key string, value string) string {
+	return escapeProperty(key, true) + "=" + escapeProperty(value, false) + file.newline
+}
+
+func trimNewline(s string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+}
+
+// continues reports whether a line ends in an odd number of backslashes.
+func continues(s string) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// splitEntry splits a logical line into its unescaped key and value. The key
+// ends at the first unescaped '=', ':' or whitespace, which may be surrounded
+// by whitespace.
+func splitEntry(content string) (string, string) {
+	end := len(content)
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.IndexByte("=: \t\f", content[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	key, rest := content[:end], strings.TrimLeft(content[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	return unescapeProperty(key), unescapeProperty(rest)
+}
+
+// unescapeProperty decodes the escape sequences in a key or value.
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var units []uint16
+	buf := &strings.Builder{}
+
+	flush := func() {
+		if len(units) > 0 {
+			buf.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i+1 >= len(runes) {
+			flush()
+			buf.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case 't':
+			flush()
+			buf.WriteByte('\t')
+		case 'n':
+			flush()
+			buf.WriteByte('\n')
+		case 'r':
+			flush()
+			buf.WriteByte('\r')
+		case 'f':
+			flush()
+			buf.WriteByte('\f')
+		case 'u':
+			if unit, ok := parseUnicodeEscape(runes[i+1:]); ok {
+				units = append(units, unit)
+				i += 4
+				continue
+			}
+
+			// Malformed escapes are kept as they are, rather than guessing
+			// at what they were meant to be.
+			flush()
+			buf.WriteString(`\u`)
+		default:
+			flush()
+			buf.WriteRune(runes[i])
+		}
+	}
+
+	flush()
+	return buf.String()
+}
+
+// parseUnicodeEscape decodes the four hexadecimal digits of a \uXXXX escape at
+// the start of the runes.
+func parseUnicodeEscape(runes []rune) (uint16, bool) {
+	if len(runes) < 4 {
+		return 0, false
+	}
+
+	var unit uint16
+	for _, r := range runes[:4] {
+		var digit rune
+		switch {
+		case r >= '0' && r <= '9':
+			digit = r - '0'
+		case r >= 'a' && r <= 'f':
+			digit = r - 'a' + 10
+		case r >= 'A' && r <= 'F':
+			digit = r - 'A' + 10
+		default:
+			return 0, false
+		}
+
+		unit = unit<<4 | uint16(digit)
+	}
+
+	return unit, true
+}
+
+// escapeProperty encodes a key or value for a properties file. Characters
+// outside of printable ASCII are written as Unicode escapes.
+func escapeProperty(s string, key bool) string {
+	buf := &strings.Builder{}
+
+	for i, r := range s {
+		switch {
+		case r == ' ':
+			if key || i == 0 {
+				buf.WriteByte('\\')
+			}
+
+			buf.WriteByte(' ')
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\f':
+			buf.WriteString(`\f`)
+		case strings.ContainsRune(`\=:#!`, r):
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(buf, `\u%04X`, unit)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	return buf.String()
+}
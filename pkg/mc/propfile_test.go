@@ -0,0 +1,191 @@
+package mc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPropertiesFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		file   string
+		values map[string]string
+	}{
+		{"vanilla.properties", map[string]string{
+			"motd":               "A Minecraft Server",
+			"server-ip":          "",
+			"level-name":         "world",
+			"spawn-protection":   "16",
+			"rcon.port":          "25575",
+			"generator-settings": "",
+		}},
+		{"edited.properties", map[string]string{
+			"motd":                "§aGreen server",
+			"level-name":          "my world",
+			"key=with:separators": "value",
+			"server-ip":           "",
+		}},
+	}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file := parseProperties(data)
+		if out := file.Bytes(); !bytes.Equal(out, data) {
+			t.Errorf("%s was written back as:\n%s", test.file, out)
+		}
+
+		for key, expected := range test.values {
+			if value, ok := file.Get(key); !ok || value != expected {
+				t.Errorf("%s: %s = %q (%v), expected %q", test.file, key, value, ok, expected)
+			}
+		}
+
+		if value, ok := file.Get("! not an entry"); ok {
+			t.Errorf("%s: comment was read as an entry with value %q", test.file, value)
+		}
+	}
+}
+
+func TestPropertiesFileSet(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "edited.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := parseProperties(data)
+
+	if file.Set("level-name", "my world") {
+		t.Error("Set reported a change for an unchanged value")
+	}
+
+	if !file.Set("motd", "Ünïcödé: #1 = ☃ \U0001F600") {
+		t.Error("Set did not report a change")
+	}
+
+	file.Set("white-list", "true")
+	file.Set(" key", " value\twith\nescapes\\")
+
+	expected := "# Edited by hand\r\n" +
+		"\r\n" +
+		`motd=\u00DCn\u00EFc\u00F6d\u00E9\: \#1 \= \u2603 \uD83D\uDE00` + "\r\n" +
+		"  ! not an entry\r\n" +
+		`level-name:my\ world` + "\r\n" +
+		`key\=with\:separators value` + "\r\n" +
+		"server-ip\r\n" +
+		"white-list=true\r\n" +
+		`\ key=\ value\twith\nescapes\\` + "\r\n"
+
+	if out := string(file.Bytes()); out != expected {
+		t.Errorf("file was written as:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	reparsed := parseProperties(file.Bytes())
+	for _, key := range []string{"motd", "white-list", " key", "level-name"} {
+		want, _ := file.Get(key)
+		if got, ok := reparsed.Get(key); !ok || got != want {
+			t.Errorf("%q was read back as %q, expected %q", key, got, want)
+		}
+	}
+}
+
+func TestPropertiesFileWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PropertiesFile)
+
+	file := newPropertiesFile()
+	file.Set("motd", "Hello")
+
+	if err := file.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readPropertiesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(read.Bytes(), file.Bytes()) {
+		t.Errorf("file was read back as:\n%s", read.Bytes())
+	}
+
+	read.Set("motd", "Goodbye")
+	if err := read.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestUnescapeProperty(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{`a\tb\nc\rd\fe`, "a\tb\nc\rd\fe"},
+		{`\=\:\#\!\ \\`, `=:#! \`},
+		{`\q`, "q"},
+		{`§§`, "§§"},
+		{`☃`, "☃"},
+		{`😀`, "\U0001F600"},
+		{`\uD83D`, "�"},
+		{`\u12zz`, `\u12zz`},
+		{`\u+123`, `\u+123`},
+		{`\u 123`, `\u 123`},
+		{`\u12`, `\u12`},
+		{`\u`, `\u`},
+		{`A\u12`, `A\u12`},
+		{`trailing\`, `trailing\`},
+	}
+
+	for _, test := range tests {
+		if value := unescapeProperty(test.input); value != test.expected {
+			t.Errorf("unescapeProperty(%q) = %q, expected %q", test.input, value, test.expected)
+		}
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	tests := []struct {
+		input    string
+		key      bool
+		expected string
+	}{
+		{"plain", false, "plain"},
+		{"a b", false, "a b"},
+		{"a b", true, `a\ b`},
+		{" lead", false, `\ lead`},
+		{"=:#!\\", false, `\=\:\#\!\\`},
+		{"\t\n\r\f", false, `\t\n\r\f`},
+		{"\x01\x7f", false, `\u0001\u007F`},
+		{"§", false, `\u00A7`},
+		{"\U0001F600", false, `\uD83D\uDE00`},
+	}
+
+	for _, test := range tests {
+		escaped := escapeProperty(test.input, test.key)
+		if escaped != test.expected {
+			t.Errorf("escapeProperty(%q, %v) = %q, expected %q", test.input, test.key, escaped, test.expected)
+		}
+
+		if value := unescapeProperty(escaped); value != test.input {
+			t.Errorf("unescapeProperty(%q) = %q, expected %q", escaped, value, test.input)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package mc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxErrorContext is the number of characters of input preceding the cursor
+// that are included in a SyntaxError message.
+const SyntaxErrorContext = 10
+
+// SyntaxError is an error in the syntax of command input at a given position.
+type SyntaxError struct {
+	Message string
+	Input   string
+	Cursor  int
+}
+
+// Error returns the error message, followed by the input leading up to the
+// position of the error.
+func (err *SyntaxError) Error() string {
+	start := err.Cursor - SyntaxErrorContext
+	prefix := "..."
+
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+
+	return fmt.Sprintf("%s at position %d: %s%s<--[HERE]", err.Message, err.Cursor, prefix, err.Input[start:err.Cursor])
+}
+
+// Token is a single argument of command input, along with its position in the
+// input.
+type Token struct {
+	// Value is the text of the argument. Quoted strings are unquoted and
+	// unescaped; all other arguments, including bracketed spans, are kept as
+	// they appear in the input.
+	Value string
+
+	// Start and End are the offsets of the first byte of the argument and the
+	// byte following the argument in the input, including any quotes.
+	Start int
+	End   int
+
+	// Quoted indicates whether the argument was a quoted string.
+	Quoted bool
+}
+
+// StringReader reads arguments from command input, keeping track of its
+// position in the input.
+type StringReader struct {
+	Input  string
+	Cursor int
+}
+
+// NewStringReader returns a StringReader at the beginning of the input.
+func NewStringReader(input string) *StringReader {
+	return &StringReader{Input: input}
+}
+
+// Tokenize splits command input into arguments separated by any amount of
+// whitespace.
+func Tokenize(input string) ([]Token, error) {
+	reader := NewStringReader(input)
+	tokens := make([]Token, 0)
+
+	for reader.SkipWhitespace(); reader.CanRead(); reader.SkipWhitespace() {
+		token, err := reader.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// CanRead reports whether there is any input remaining after the cursor.
+func (reader *StringReader) CanRead() bool {
+	return reader.Cursor < len(reader.Input)
+}
+
+// Peek returns the byte at the cursor without advancing it.
+func (reader *StringReader) Peek() byte {
+	return reader.Input[reader.Cursor]
+}
+
+// Read returns the byte at the cursor and advances the cursor.
+func (reader *StringReader) Read() byte {
+	c := reader.Input[reader.Cursor]
+	reader.Cursor++
+	return c
+}
+
+// Remaining returns the input after the cursor.
+func (reader *StringReader) Remaining() string {
+	return reader.Input[reader.Cursor:]
+}
+
+// SkipWhitespace advances the cursor past any whitespace.
+func (reader *StringReader) SkipWhitespace() {
+	for reader.CanRead() && isWhitespace(reader.Peek()) {
+		reader.Cursor++
+	}
+}
+
+// ReadToken reads a single argument at the cursor: either a quoted string, or
+// a run of non-whitespace characters in which bracketed spans are kept intact.
+func (reader *StringReader) ReadToken() (Token, error) {
+	token := Token{Start: reader.Cursor}
+
+	if reader.CanRead() && isQuote(reader.Peek()) {
+		value, err := reader.ReadQuotedString()
+		if err != nil {
+			return token, err
+		}
+
+		token.Value = value
+		token.End = reader.Cursor
+		token.Quoted = true
+
+		return token, nil
+	}
+
+	for reader.CanRead() && !isWhitespace(reader.Peek()) {
+		switch reader.Peek() {
+		case '{', '[':
+			if _, err := reader.ReadSpan(); err != nil {
+				return token, err
+			}
+		default:
+			reader.Cursor++
+		}
+	}
+
+	token.End = reader.Cursor
+	token.Value = reader.Input[token.Start:token.End]
+
+	return token, nil
+}
+
+// ReadUnquotedString reads a run of non-whitespace characters at the cursor.
+func (reader *StringReader) ReadUnquotedString() string {
+	start := reader.Cursor
+
+	for reader.CanRead() && !isWhitespace(reader.Peek()) {
+		reader.Cursor++
+	}
+
+	return reader.Input[start:reader.Cursor]
+}
+
+// ReadQuotedString reads a single- or double-quoted string at the cursor, in
+// which the quote character and backslash may be escaped with a backslash.
+func (reader *StringReader) ReadQuotedString() (string, error) {
+	if !reader.CanRead() || !isQuote(reader.Peek()) {
+		return "", reader.syntaxError("Expected quote to start a string")
+	}
+
+	quote := reader.Read()
+	result := &strings.Builder{}
+	escaped := false
+
+	for reader.CanRead() {
+		c := reader.Read()
+
+		switch {
+		case escaped:
+			if c != quote && c != '\\' {
+				reader.Cursor--
+				return "", reader.syntaxError(fmt.Sprintf("Invalid escape sequence '%c' in quoted string", c))
+			}
+
+			result.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == quote:
+			return result.String(), nil
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return "", reader.syntaxError("Unclosed quoted string")
+}
+
+// ReadSpan reads a balanced span of {} and [] brackets at the cursor, such as
+// a JSON text component or an NBT compound, and returns it as it appears in
+// the input. Brackets inside quoted strings within the span are ignored.
+func (reader *StringReader) ReadSpan() (string, error) {
+	start := reader.Cursor
+	stack := make([]byte, 0)
+
+	for reader.CanRead() {
+		switch c := reader.Peek(); c {
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return "", reader.syntaxError(fmt.Sprintf("Unexpected '%c'", c))
+			}
+
+			stack = stack[:len(stack)-1]
+		case '"', '\'':
+			if err := reader.skipQuotedString(); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		reader.Cursor++
+
+		if len(stack) == 0 {
+			return reader.Input[start:reader.Cursor], nil
+		}
+	}
+
+	return "", reader.syntaxError(fmt.Sprintf("Expected '%c'", stack[len(stack)-1]))
+}
+
+// skipQuotedString advances the cursor past a quoted string without
+// interpreting its escape sequences, which may follow JSON or SNBT rules.
+func (reader *StringReader) skipQuotedString() error {
+	quote := reader.Read()
+
+	for reader.CanRead() {
+		switch reader.Read() {
+		case '\\':
+			if reader.CanRead() {
+				reader.Cursor++
+			}
+		case quote:
+			return nil
+		}
+	}
+
+	return reader.syntaxError("Unclosed quoted string")
+}
+
+func (reader *StringReader) syntaxError(message string) *SyntaxError {
+	return &SyntaxError{
+		Message: message,
+		Input:   reader.Input,
+		Cursor:  reader.Cursor,
+	}
+}
+
+func isQuote(c byte) bool {
+	return c == '"' || c == '\''
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
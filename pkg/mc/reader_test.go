@@ -0,0 +1,88 @@
+package mc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input  string
+		tokens []Token
+	}{
+		{"", []Token{}},
+		{"   ", []Token{}},
+		{"list", []Token{{"list", 0, 4, false}}},
+		{" say  hi\tthere ", []Token{{"say", 1, 4, false}, {"hi", 6, 8, false}, {"there", 9, 14, false}}},
+		{`"a b" 'c d'`, []Token{{"a b", 0, 5, true}, {"c d", 6, 11, true}}},
+		{`"say \"hi\"" 'it\'s' "a\\b"`, []Token{{`say "hi"`, 0, 12, true}, {"it's", 13, 20, true}, {`a\b`, 21, 27, true}}},
+		{`'say "hi"' "it's"`, []Token{{`say "hi"`, 0, 10, true}, {"it's", 11, 17, true}}},
+		{`""`, []Token{{"", 0, 2, true}}},
+		{`a"b"`, []Token{{`a"b"`, 0, 4, false}}},
+		{"@a[name=x, tag=y] hi", []Token{{"@a[name=x, tag=y]", 0, 17, false}, {"hi", 18, 20, false}}},
+		{`{"text":"a b","extra":[{"text":"]}"}]}`, []Token{{`{"text":"a b","extra":[{"text":"]}"}]}`, 0, 38, false}}},
+		{`@a[name="a]b"] {"text":"\"}"}`, []Token{{`@a[name="a]b"]`, 0, 14, false}, {`{"text":"\"}"}`, 15, 29, false}}},
+		{"[1, [2, 3]]x y", []Token{{"[1, [2, 3]]x", 0, 12, false}, {"y", 13, 14, false}}},
+	}
+
+	for _, test := range tests {
+		tokens, err := Tokenize(test.input)
+		if err != nil {
+			t.Errorf("Tokenize(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("Tokenize(%q) = %+v, expected %+v", test.input, tokens, test.tokens)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		cursor  int
+	}{
+		{`say "hi`, "Unclosed quoted string", 7},
+		{`say 'hi\'`, "Unclosed quoted string", 9},
+		{`say "a\nb"`, "Invalid escape sequence 'n' in quoted string", 7},
+		{`tellraw @a {"text":"hi"`, "Expected '}'", 23},
+		{`tellraw @a {"text":"hi]`, "Unclosed quoted string", 23},
+		{`tellraw @a [{"text":"hi"]`, "Unexpected ']'", 24},
+		{`x [}`, "Unexpected '}'", 3},
+		{`@a[name=x`, "Expected ']'", 9},
+	}
+
+	for _, test := range tests {
+		_, err := Tokenize(test.input)
+
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Tokenize(%q) returned %v, expected a syntax error", test.input, err)
+			continue
+		}
+
+		if serr.Message != test.message || serr.Cursor != test.cursor || serr.Input != test.input {
+			t.Errorf("Tokenize(%q) returned %q at %d, expected %q at %d",
+				test.input, serr.Message, serr.Cursor, test.message, test.cursor)
+		}
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		err      *SyntaxError
+		expected string
+	}{
+		{&SyntaxError{"Unclosed quoted string", `say "hi`, 7}, `Unclosed quoted string at position 7: say "hi<--[HERE]`},
+		{&SyntaxError{"Expected '}'", `tellraw @a {"text":"hi"`, 23}, `Expected '}' at position 23: ...text":"hi"<--[HERE]`},
+		{&SyntaxError{"Missing selector type", "@", 1}, "Missing selector type at position 1: @<--[HERE]"},
+	}
+
+	for _, test := range tests {
+		if msg := test.err.Error(); msg != test.expected {
+			t.Errorf("Error() = %q, expected %q", msg, test.expected)
+		}
+	}
+}
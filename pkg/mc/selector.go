@@ -0,0 +1,526 @@
+package mc
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SelectorOrigin is implemented by command origins that exist in the world,
+// so that selectors are evaluated relative to them. Origins that do not
+// implement it, such as the console and RCON, are treated as being at the
+// world spawn with no executing entity.
+type SelectorOrigin interface {
+	Origin
+	Entity() *Entity
+}
+
+// WorldSpawn is the position at which selectors are evaluated for origins
+// that have no position of their own.
+var WorldSpawn = Position{}
+
+// SelectorContext is the position and executing entity against which a
+// selector is evaluated.
+type SelectorContext struct {
+	Position Position
+	Self     *Entity
+}
+
+// NewSelectorContext returns the context in which selectors sent by the
+// given origin are evaluated.
+func NewSelectorContext(origin Origin) *SelectorContext {
+	if so, ok := origin.(SelectorOrigin); ok {
+		if self := so.Entity(); self != nil {
+			return &SelectorContext{
+				Position: self.Position,
+				Self:     self,
+			}
+		}
+	}
+
+	return &SelectorContext{
+		Position: WorldSpawn,
+	}
+}
+
+// SortOrder is the order in which selected entities are sorted before a limit
+// is applied.
+type SortOrder int
+
+// Sort orders supported by the sort selector argument.
+const (
+	SortArbitrary SortOrder = iota
+	SortNearest
+	SortFurthest
+	SortRandom
+)
+
+// String maps a SortOrder to its name.
+func (order SortOrder) String() string {
+	switch order {
+	case SortArbitrary:
+		return "arbitrary"
+	case SortNearest:
+		return "nearest"
+	case SortFurthest:
+		return "furthest"
+	case SortRandom:
+		return "random"
+	}
+
+	return ""
+}
+
+// Range is an inclusive range of numbers, either end of which may be open.
+type Range struct {
+	Min, Max       float64
+	HasMin, HasMax bool
+}
+
+// ParseRange parses a range in the form "n", "min..", "..max" or "min..max".
+func ParseRange(s string) (*Range, error) {
+	r := &Range{}
+
+	min, max := s, s
+	if i := strings.Index(s, ".."); i >= 0 {
+		min, max = s[:i], s[i+2:]
+	}
+
+	if min == "" && max == "" {
+		return nil, fmt.Errorf("Expected value or range of values")
+	}
+
+	if min != "" {
+		v, err := strconv.ParseFloat(min, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number '%s'", min)
+		}
+
+		r.Min, r.HasMin = v, true
+	}
+
+	if max != "" {
+		v, err := strconv.ParseFloat(max, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number '%s'", max)
+		}
+
+		r.Max, r.HasMax = v, true
+	}
+
+	if r.HasMin && r.HasMax && r.Min > r.Max {
+		return nil, fmt.Errorf("Min cannot be bigger than max")
+	}
+
+	return r, nil
+}
+
+// Contains reports whether the value is within the range.
+func (r *Range) Contains(v float64) bool {
+	return (!r.HasMin || v >= r.Min) && (!r.HasMax || v <= r.Max)
+}
+
+type selectorFilter struct {
+	Value  string
+	Negate bool
+}
+
+// Selector is a parsed target selector, such as @a[distance=..10,limit=3].
+type Selector struct {
+	// Kind is the selector variable: 'p', 'a', 'r', 's' or 'e'.
+	Kind byte
+
+	Limit    int
+	Sort     SortOrder
+	Distance *Range
+	Level    *Range
+
+	X, Y, Z    *float64
+	DX, DY, DZ *float64
+
+	gameModes []selectorFilter
+	names     []selectorFilter
+	tags      []selectorFilter
+	types     []selectorFilter
+}
+
+// IsSelector reports whether a command argument is a target selector rather
+// than a player name or UUID.
+func IsSelector(arg string) bool {
+	return strings.HasPrefix(arg, "@")
+}
+
+// ParseSelector parses a target selector.
+func ParseSelector(input string) (*Selector, error) {
+	reader := NewStringReader(input)
+
+	if !reader.CanRead() || reader.Read() != '@' {
+		return nil, reader.syntaxError("Expected selector")
+	}
+
+	if !reader.CanRead() {
+		return nil, reader.syntaxError("Missing selector type")
+	}
+
+	sel := &Selector{Kind: reader.Read()}
+
+	switch sel.Kind {
+	case 'p':
+		sel.Limit, sel.Sort = 1, SortNearest
+	case 'r':
+		sel.Limit, sel.Sort = 1, SortRandom
+	case 's':
+		sel.Limit = 1
+	case 'a', 'e':
+	default:
+		reader.Cursor--
+		return nil, reader.syntaxError(fmt.Sprintf("Unknown selector type '@%c'", sel.Kind))
+	}
+
+	if reader.CanRead() && reader.Peek() == '[' {
+		reader.Cursor++
+
+		if err := sel.parseArguments(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	if reader.CanRead() {
+		return nil, reader.syntaxError("Unexpected characters after selector")
+	}
+
+	return sel, nil
+}
+
+func (sel *Selector) parseArguments(reader *StringReader) error {
+	for reader.SkipWhitespace(); reader.CanRead() && reader.Peek() != ']'; reader.SkipWhitespace() {
+		start := reader.Cursor
+		for reader.CanRead() && !strings.ContainsRune("=,]", rune(reader.Peek())) && !isWhitespace(reader.Peek()) {
+			reader.Cursor++
+		}
+
+		key := reader.Input[start:reader.Cursor]
+
+		reader.SkipWhitespace()
+		if !reader.CanRead() || reader.Read() != '=' {
+			reader.Cursor = start
+			return reader.syntaxError(fmt.Sprintf("Expected value for option '%s'", key))
+		}
+
+		reader.SkipWhitespace()
+		valueStart := reader.Cursor
+
+		value, err := readSelectorValue(reader)
+		if err != nil {
+			return err
+		}
+
+		if err := sel.setArgument(key, value); err != nil {
+			reader.Cursor = valueStart
+			return reader.syntaxError(err.Error())
+		}
+
+		reader.SkipWhitespace()
+		if reader.CanRead() && reader.Peek() == ',' {
+			reader.Cursor++
+			continue
+		}
+
+		if !reader.CanRead() || reader.Peek() != ']' {
+			return reader.syntaxError("Expected end of options")
+		}
+	}
+
+	if !reader.CanRead() {
+		return reader.syntaxError("Expected end of options")
+	}
+
+	reader.Cursor++
+	return nil
+}
+
+func readSelectorValue(reader *StringReader) (string, error) {
+	prefix := ""
+	if reader.CanRead() && reader.Peek() == '!' {
+		prefix = "!"
+		reader.Cursor++
+		reader.SkipWhitespace()
+	}
+
+	if reader.CanRead() && isQuote(reader.Peek()) {
+		value, err := reader.ReadQuotedString()
+		return prefix + value, err
+	}
+
+	start := reader.Cursor
+	for reader.CanRead() && reader.Peek() != ',' && reader.Peek() != ']' && !isWhitespace(reader.Peek()) {
+		reader.Cursor++
+	}
+
+	return prefix + reader.Input[start:reader.Cursor], nil
+}
+
+func newSelectorFilter(value string) selectorFilter {
+	if strings.HasPrefix(value, "!") {
+		return selectorFilter{Value: value[1:], Negate: true}
+	}
+
+	return selectorFilter{Value: value}
+}
+
+func (sel *Selector) setArgument(key, value string) error {
+	switch key {
+	case "limit":
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Invalid integer '%s'", value)
+		}
+
+		if limit < 1 {
+			return fmt.Errorf("Limit must be at least 1")
+		}
+
+		if sel.Kind == 's' {
+			return fmt.Errorf("Option 'limit' isn't applicable here")
+		}
+
+		sel.Limit = limit
+	case "sort":
+		if sel.Kind == 's' {
+			return fmt.Errorf("Option 'sort' isn't applicable here")
+		}
+
+		for _, order := range []SortOrder{SortArbitrary, SortNearest, SortFurthest, SortRandom} {
+			if order.String() == value {
+				sel.Sort = order
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Invalid or unknown sort type '%s'", value)
+	case "distance", "level":
+		r, err := ParseRange(value)
+		if err != nil {
+			return err
+		}
+
+		if key == "distance" {
+			if r.HasMin && r.Min < 0 || r.HasMax && r.Max < 0 {
+				return fmt.Errorf("Distance cannot be negative")
+			}
+
+			sel.Distance = r
+		} else {
+			sel.Level = r
+		}
+	case "x", "y", "z", "dx", "dy", "dz":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid number '%s'", value)
+		}
+
+		switch key {
+		case "x":
+			sel.X = &v
+		case "y":
+			sel.Y = &v
+		case "z":
+			sel.Z = &v
+		case "dx":
+			sel.DX = &v
+		case "dy":
+			sel.DY = &v
+		case "dz":
+			sel.DZ = &v
+		}
+	case "gamemode":
+		filter := newSelectorFilter(value)
+		if _, err := ParseGameMode(filter.Value); err != nil {
+			return err
+		}
+
+		sel.gameModes = append(sel.gameModes, filter)
+	case "name":
+		sel.names = append(sel.names, newSelectorFilter(value))
+	case "tag":
+		sel.tags = append(sel.tags, newSelectorFilter(value))
+	case "type":
+		if sel.Kind != 'e' {
+			return fmt.Errorf("Option 'type' isn't applicable here")
+		}
+
+		filter := newSelectorFilter(value)
+		filter.Value = NamespacedID(filter.Value)
+		sel.types = append(sel.types, filter)
+	default:
+		return fmt.Errorf("Unknown option '%s'", key)
+	}
+
+	return nil
+}
+
+// PlayersOnly reports whether the selector can only select players.
+func (sel *Selector) PlayersOnly() bool {
+	if sel.Kind != 'e' {
+		return true
+	}
+
+	for _, filter := range sel.types {
+		if !filter.Negate && filter.Value == PlayerEntityType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Select returns the entities matching the selector in the given context,
+// sorted and limited according to its arguments.
+func (sel *Selector) Select(ctx *SelectorContext, entities []*Entity) []*Entity {
+	origin := ctx.Position
+	if sel.X != nil {
+		origin.X = *sel.X
+	}
+
+	if sel.Y != nil {
+		origin.Y = *sel.Y
+	}
+
+	if sel.Z != nil {
+		origin.Z = *sel.Z
+	}
+
+	candidates := entities
+	if sel.Kind == 's' {
+		candidates = make([]*Entity, 0, 1)
+		if ctx.Self != nil {
+			candidates = append(candidates, ctx.Self)
+		}
+	}
+
+	selected := make([]*Entity, 0)
+	for _, entity := range candidates {
+		if sel.matches(origin, entity) {
+			selected = append(selected, entity)
+		}
+	}
+
+	switch sel.Sort {
+	case SortNearest:
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Position.Distance(origin) < selected[j].Position.Distance(origin)
+		})
+	case SortFurthest:
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Position.Distance(origin) > selected[j].Position.Distance(origin)
+		})
+	case SortRandom:
+		rand.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+	}
+
+	if sel.Limit > 0 && len(selected) > sel.Limit {
+		selected = selected[:sel.Limit]
+	}
+
+	return selected
+}
+
+func (sel *Selector) matches(origin Position, entity *Entity) bool {
+	if sel.Kind != 'e' && !entity.IsPlayer() {
+		return false
+	}
+
+	if sel.Distance != nil && !sel.Distance.Contains(entity.Position.Distance(origin)) {
+		return false
+	}
+
+	if !sel.inVolume(origin, entity.Position) {
+		return false
+	}
+
+	if sel.Level != nil && (!entity.IsPlayer() || !sel.Level.Contains(float64(entity.Level))) {
+		return false
+	}
+
+	for _, filter := range sel.gameModes {
+		if !entity.IsPlayer() || (entity.GameMode.String() == filter.Value) == filter.Negate {
+			return false
+		}
+	}
+
+	for _, filter := range sel.names {
+		if (entity.Name == filter.Value) == filter.Negate {
+			return false
+		}
+	}
+
+	for _, filter := range sel.types {
+		if (entity.Type == filter.Value) == filter.Negate {
+			return false
+		}
+	}
+
+	for _, filter := range sel.tags {
+		var has bool
+		if filter.Value == "" {
+			has = len(entity.Tags) == 0
+		} else {
+			has = entity.HasTag(filter.Value)
+		}
+
+		if has == filter.Negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (sel *Selector) inVolume(origin, pos Position) bool {
+	if sel.DX == nil && sel.DY == nil && sel.DZ == nil {
+		return true
+	}
+
+	for _, axis := range []struct {
+		delta       *float64
+		origin, pos float64
+	}{{sel.DX, origin.X, pos.X}, {sel.DY, origin.Y, pos.Y}, {sel.DZ, origin.Z, pos.Z}} {
+		var d float64
+		if axis.delta != nil {
+			d = *axis.delta
+		}
+
+		min, max := math.Min(axis.origin, axis.origin+d), math.Max(axis.origin, axis.origin+d)+1
+		if axis.pos < min || axis.pos > max {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SelectTargets resolves a command argument, which may be a target selector,
+// a player name or an entity UUID, to the matching entities.
+func SelectTargets(arg string, ctx *SelectorContext, entities []*Entity) ([]*Entity, error) {
+	if IsSelector(arg) {
+		sel, err := ParseSelector(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return sel.Select(ctx, entities), nil
+	}
+
+	for _, entity := range entities {
+		if entity.UUID == arg || entity.IsPlayer() && strings.EqualFold(entity.Name, arg) {
+			return []*Entity{entity}, nil
+		}
+	}
+
+	return []*Entity{}, nil
+}
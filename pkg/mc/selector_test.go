@@ -0,0 +1,264 @@
+package mc
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  byte
+		limit int
+		sort  SortOrder
+	}{
+		{"@p", 'p', 1, SortNearest},
+		{"@r", 'r', 1, SortRandom},
+		{"@s", 's', 1, SortArbitrary},
+		{"@a", 'a', 0, SortArbitrary},
+		{"@e", 'e', 0, SortArbitrary},
+		{"@a[]", 'a', 0, SortArbitrary},
+		{"@a[distance=..10,gamemode=survival,limit=3,sort=nearest]", 'a', 3, SortNearest},
+		{"@a[ limit = 2 , sort = furthest ]", 'a', 2, SortFurthest},
+		{"@p[sort=random]", 'p', 1, SortRandom},
+		{"@e[type=minecraft:cow,tag=x]", 'e', 0, SortArbitrary},
+		{`@a[name="Steve Jobs"]`, 'a', 0, SortArbitrary},
+		{"@a[name=!Steve,tag=,gamemode=!creative]", 'a', 0, SortArbitrary},
+		{"@a[x=1.5,y=-2,z=3,dx=4,dy=5,dz=6,level=1..]", 'a', 0, SortArbitrary},
+	}
+
+	for _, test := range tests {
+		sel, err := ParseSelector(test.input)
+		if err != nil {
+			t.Errorf("ParseSelector(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if sel.Kind != test.kind || sel.Limit != test.limit || sel.Sort != test.sort {
+			t.Errorf("ParseSelector(%q) = @%c limit %d sort %s, expected @%c limit %d sort %s",
+				test.input, sel.Kind, sel.Limit, sel.Sort, test.kind, test.limit, test.sort)
+		}
+	}
+}
+
+func TestParseSelectorOptions(t *testing.T) {
+	sel, err := ParseSelector("@a[distance=2..10,level=..5,x=1,z=-3,dy=2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *sel.Distance != (Range{Min: 2, Max: 10, HasMin: true, HasMax: true}) {
+		t.Errorf("distance = %+v", *sel.Distance)
+	}
+
+	if *sel.Level != (Range{Max: 5, HasMax: true}) {
+		t.Errorf("level = %+v", *sel.Level)
+	}
+
+	if sel.X == nil || *sel.X != 1 || sel.Y != nil || sel.Z == nil || *sel.Z != -3 {
+		t.Errorf("x, y, z = %v, %v, %v", sel.X, sel.Y, sel.Z)
+	}
+
+	if sel.DX != nil || sel.DY == nil || *sel.DY != 2 || sel.DZ != nil {
+		t.Errorf("dx, dy, dz = %v, %v, %v", sel.DX, sel.DY, sel.DZ)
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		cursor  int
+	}{
+		{"@", "Missing selector type", 1},
+		{"@x", "Unknown selector type '@x'", 1},
+		{"@a[", "Expected end of options", 3},
+		{"@a[limit=3", "Expected end of options", 10},
+		{"@a[limit]", "Expected value for option 'limit'", 3},
+		{"@a[limit=0]", "Limit must be at least 1", 9},
+		{"@a[limit=x]", "Invalid integer 'x'", 9},
+		{"@s[limit=2]", "Option 'limit' isn't applicable here", 9},
+		{"@s[sort=random]", "Option 'sort' isn't applicable here", 8},
+		{"@a[sort=closest]", "Invalid or unknown sort type 'closest'", 8},
+		{"@a[distance=-1]", "Distance cannot be negative", 12},
+		{"@a[distance=5..1]", "Min cannot be bigger than max", 12},
+		{"@a[distance=..]", "Expected value or range of values", 12},
+		{"@a[gamemode=peaceful]", "Invalid game mode 'peaceful'", 12},
+		{"@a[type=cow]", "Option 'type' isn't applicable here", 8},
+		{"@a[x=north]", "Invalid number 'north'", 5},
+		{"@a[color=red]", "Unknown option 'color'", 9},
+		{"@a[limit=1 tag=x]", "Expected end of options", 11},
+		{"@a[]x", "Unexpected characters after selector", 4},
+	}
+
+	for _, test := range tests {
+		_, err := ParseSelector(test.input)
+
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseSelector(%q) returned %v, expected a syntax error", test.input, err)
+			continue
+		}
+
+		if serr.Message != test.message || serr.Cursor != test.cursor {
+			t.Errorf("ParseSelector(%q) returned %q at %d, expected %q at %d",
+				test.input, serr.Message, serr.Cursor, test.message, test.cursor)
+		}
+	}
+}
+
+// testEntities returns players and other entities at known positions:
+//
+//	Alice   survival   (0, 64, 0)     level 5   tags: red
+//	Bob     creative   (10, 64, 0)    level 0   tags: red, blue
+//	Carol   survival   (-30, 70, 40)  level 30
+//	cow                (2, 64, 2)               tags: x
+//	zombie             (100, 64, 0)
+func testEntities() []*Entity {
+	alice := NewPlayerEntity(&Player{UUID: "a", Name: "Alice"}, Position{0, 64, 0}, Survival)
+	alice.Level = 5
+	alice.Tags = []string{"red"}
+
+	bob := NewPlayerEntity(&Player{UUID: "b", Name: "Bob"}, Position{10, 64, 0}, Creative)
+	bob.Tags = []string{"red", "blue"}
+
+	carol := NewPlayerEntity(&Player{UUID: "c", Name: "Carol"}, Position{-30, 70, 40}, Survival)
+	carol.Level = 30
+
+	cow := &Entity{UUID: "d", Type: "minecraft:cow", Position: Position{2, 64, 2}, Tags: []string{"x"}}
+	zombie := &Entity{UUID: "e", Type: "minecraft:zombie", Position: Position{100, 64, 0}}
+
+	return []*Entity{alice, bob, carol, cow, zombie}
+}
+
+func selectedIDs(entities []*Entity) []string {
+	ids := make([]string, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.UUID
+	}
+
+	return ids
+}
+
+func TestSelect(t *testing.T) {
+	spawn := &SelectorContext{Position: Position{0, 64, 0}}
+	entities := testEntities()
+
+	tests := []struct {
+		input    string
+		ctx      *SelectorContext
+		expected []string
+	}{
+		{"@a", spawn, []string{"a", "b", "c"}},
+		{"@e", spawn, []string{"a", "b", "c", "d", "e"}},
+		{"@p", spawn, []string{"a"}},
+		{"@p", &SelectorContext{Position: Position{9, 64, 0}}, []string{"b"}},
+		{"@p[gamemode=survival]", &SelectorContext{Position: Position{9, 64, 0}}, []string{"a"}},
+		{"@a[sort=nearest]", &SelectorContext{Position: Position{9, 64, 0}}, []string{"b", "a", "c"}},
+		{"@a[sort=furthest,limit=2]", spawn, []string{"c", "b"}},
+		{"@a[distance=..10]", spawn, []string{"a", "b"}},
+		{"@a[distance=1..]", spawn, []string{"b", "c"}},
+		{"@a[distance=..10,gamemode=survival,limit=3,sort=nearest]", spawn, []string{"a"}},
+		{"@a[x=-30,y=70,z=40,distance=..1]", spawn, []string{"c"}},
+		{"@e[x=0,y=64,z=0,dx=5,dy=0,dz=5]", spawn, []string{"a", "d"}},
+		{"@e[x=5,y=60,z=5,dx=-10,dy=10,dz=-10]", spawn, []string{"a", "d"}},
+		{"@a[gamemode=!survival]", spawn, []string{"b"}},
+		{"@a[level=5..]", spawn, []string{"a", "c"}},
+		{"@e[level=..100]", spawn, []string{"a", "b", "c"}},
+		{"@a[name=Bob]", spawn, []string{"b"}},
+		{"@a[name=!Bob]", spawn, []string{"a", "c"}},
+		{"@a[tag=red,tag=!blue]", spawn, []string{"a"}},
+		{"@a[tag=]", spawn, []string{"c"}},
+		{"@e[tag=!]", spawn, []string{"a", "b", "d"}},
+		{"@e[type=minecraft:cow,tag=x]", spawn, []string{"d"}},
+		{"@e[type=cow]", spawn, []string{"d"}},
+		{"@e[type=!player,type=!zombie]", spawn, []string{"d"}},
+		{"@s", spawn, []string{}},
+		{"@s", &SelectorContext{Position: Position{10, 64, 0}, Self: entities[1]}, []string{"b"}},
+		{"@s[gamemode=survival]", &SelectorContext{Self: entities[1]}, []string{}},
+		{"@a[limit=2]", spawn, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		sel, err := ParseSelector(test.input)
+		if err != nil {
+			t.Errorf("ParseSelector(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if ids := selectedIDs(sel.Select(test.ctx, entities)); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s selected %v, expected %v", test.input, ids, test.expected)
+		}
+	}
+}
+
+func TestSelectRandom(t *testing.T) {
+	sel, err := ParseSelector("@r[limit=5]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := selectedIDs(sel.Select(&SelectorContext{}, testEntities()))
+	sort.Strings(ids)
+
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Errorf("@r[limit=5] selected %v, expected every player", ids)
+	}
+}
+
+func TestPlayersOnly(t *testing.T) {
+	tests := map[string]bool{
+		"@a":                              true,
+		"@p":                              true,
+		"@s":                              true,
+		"@e":                              false,
+		"@e[type=player]":                 true,
+		"@e[type=!player]":                false,
+		"@e[type=cow]":                    false,
+		"@e[type=minecraft:player,tag=x]": true,
+	}
+
+	for input, expected := range tests {
+		sel, err := ParseSelector(input)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q) returned error: %v", input, err)
+		}
+
+		if sel.PlayersOnly() != expected {
+			t.Errorf("%s.PlayersOnly() = %v, expected %v", input, !expected, expected)
+		}
+	}
+}
+
+func TestSelectTargets(t *testing.T) {
+	entities := testEntities()
+	ctx := &SelectorContext{}
+
+	tests := []struct {
+		arg      string
+		expected []string
+	}{
+		{"Bob", []string{"b"}},
+		{"bob", []string{"b"}},
+		{"d", []string{"d"}},
+		{"Dave", []string{}},
+		{"@a[name=Carol]", []string{"c"}},
+	}
+
+	for _, test := range tests {
+		selected, err := SelectTargets(test.arg, ctx, entities)
+		if err != nil {
+			t.Errorf("SelectTargets(%q) returned error: %v", test.arg, err)
+			continue
+		}
+
+		if ids := selectedIDs(selected); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("SelectTargets(%q) = %v, expected %v", test.arg, ids, test.expected)
+		}
+	}
+
+	if _, err := SelectTargets("@a[", ctx, entities); err == nil {
+		t.Error("SelectTargets accepted an invalid selector")
+	}
+}
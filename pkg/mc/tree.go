@@ -0,0 +1,255 @@
+package mc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jbhannah/gophermine/pkg/runner"
+)
+
+// ServerCompleter is the key of the server's Completer in subcontexts of the
+// running server.
+const ServerCompleter runner.ContextKey = "completer"
+
+// ArgumentType is the type of value accepted by a node of the command tree.
+type ArgumentType int
+
+const (
+	// LiteralArgument matches the name of the node exactly.
+	LiteralArgument ArgumentType = iota
+
+	// WordArgument matches a single unquoted word.
+	WordArgument
+
+	// IntegerArgument matches a single integer.
+	IntegerArgument
+
+	// GreedyStringArgument matches all remaining input.
+	GreedyStringArgument
+
+	// PlayersArgument matches a player name, UUID, or a selector that selects
+	// only players.
+	PlayersArgument
+
+	// EntitiesArgument matches a player name, UUID, or any selector.
+	EntitiesArgument
+
+	// GameProfileArgument matches the name of a player who may be offline, or
+	// a selector of online players.
+	GameProfileArgument
+
+	// ComponentArgument matches a JSON text component in the remaining input.
+	ComponentArgument
+)
+
+// Completer produces suggestions for partial command input.
+type Completer interface {
+	Complete(input string, cursor int) *Suggestions
+}
+
+// SuggestionContext provides the values that are suggested for arguments.
+type SuggestionContext interface {
+	PlayerNames() []string
+}
+
+// CommandNode is a node in the tree describing the syntax of all commands.
+type CommandNode struct {
+	Name       string
+	Type       ArgumentType
+	Executable bool
+	Children   []*CommandNode
+}
+
+// NewLiteral returns a node that matches its name exactly.
+func NewLiteral(name string, executable bool, children ...*CommandNode) *CommandNode {
+	return &CommandNode{
+		Name:       name,
+		Type:       LiteralArgument,
+		Executable: executable,
+		Children:   children,
+	}
+}
+
+// NewArgument returns a node that matches a value of the given type.
+func NewArgument(name string, at ArgumentType, executable bool, children ...*CommandNode) *CommandNode {
+	return &CommandNode{
+		Name:       name,
+		Type:       at,
+		Executable: executable,
+		Children:   children,
+	}
+}
+
+var commandTree = &CommandNode{
+	Children: []*CommandNode{
+		NewLiteral(CompleteCommandName, false,
+			NewArgument("input", GreedyStringArgument, true)),
+		NewLiteral(ListCommandName, true),
+		NewLiteral(RCONLockoutsCommandName, true,
+			NewLiteral("clear", true,
+				NewArgument("address", WordArgument, true)),
+			NewLiteral("list", true)),
+		NewLiteral(ReloadCommandName, true),
+		NewLiteral(SayCommandName, false,
+			NewArgument("message", GreedyStringArgument, true)),
+		NewLiteral(StopCommandName, true),
+		NewLiteral(TellrawCommandName, false,
+			NewArgument("targets", PlayersArgument, false,
+				NewArgument("message", ComponentArgument, true))),
+		NewLiteral(WhitelistCommandName, false,
+			NewLiteral("add", false,
+				NewArgument("targets", GameProfileArgument, true)),
+			NewLiteral("list", true),
+			NewLiteral("off", true),
+			NewLiteral("on", true),
+			NewLiteral("reload", true),
+			NewLiteral("remove", false,
+				NewArgument("targets", GameProfileArgument, true))),
+	},
+}
+
+// CommandTree returns the root node of the tree of all commands.
+func CommandTree() *CommandNode {
+	return commandTree
+}
+
+// Suggestions are the possible replacements for a range of command input.
+type Suggestions struct {
+	Start   int
+	Length  int
+	Matches []string
+}
+
+// Apply returns the input with each of the suggestions applied.
+func (s *Suggestions) Apply(input string) []string {
+	applied := make([]string, len(s.Matches))
+
+	for i, match := range s.Matches {
+		applied[i] = input[:s.Start] + match + input[s.Start+s.Length:]
+	}
+
+	return applied
+}
+
+// Suggest walks the tree from the node along the input up to the cursor, and
+// returns suggestions for the argument being typed at the cursor.
+func (node *CommandNode) Suggest(input string, cursor int, ctx SuggestionContext) *Suggestions {
+	if cursor > len(input) {
+		cursor = len(input)
+	}
+
+	reader := NewStringReader(input[:cursor])
+	if reader.CanRead() && reader.Peek() == '/' {
+		reader.Cursor++
+	}
+
+	suggestions := &Suggestions{Start: cursor, Matches: make([]string, 0)}
+
+	for {
+		reader.SkipWhitespace()
+		start := reader.Cursor
+
+		token, err := reader.ReadToken()
+		if !reader.CanRead() {
+			// The argument at the cursor is incomplete, or the cursor follows
+			// whitespace and no argument has been started.
+			suggestions.Start = start
+			suggestions.Length = cursor - start
+			suggestions.Matches = node.suggestChildren(input[start:cursor], ctx)
+
+			return suggestions
+		}
+
+		if err != nil {
+			return suggestions
+		}
+
+		next := node.match(token)
+		if next == nil || next.greedy() {
+			return suggestions
+		}
+
+		node = next
+	}
+}
+
+func (node *CommandNode) match(token Token) *CommandNode {
+	if !token.Quoted {
+		if child := node.literal(token.Value); child != nil {
+			return child
+		}
+	}
+
+	return node.argument()
+}
+
+// literal returns the literal child of the node with the name, if any.
+func (node *CommandNode) literal(name string) *CommandNode {
+	for _, child := range node.Children {
+		if child.Type == LiteralArgument && child.Name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// argument returns the first child of the node that is not a literal, if any.
+func (node *CommandNode) argument() *CommandNode {
+	for _, child := range node.Children {
+		if child.Type != LiteralArgument {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// greedy reports whether the node matches all remaining input.
+func (node *CommandNode) greedy() bool {
+	return node.Type == GreedyStringArgument || node.Type == ComponentArgument
+}
+
+// takesRest reports whether the argument at the cursor of the reader is
+// matched by a child of the node that takes all remaining input, rather than
+// by a literal.
+func (node *CommandNode) takesRest(reader *StringReader) bool {
+	arg := node.argument()
+	if arg == nil || !arg.greedy() {
+		return false
+	}
+
+	word := reader.Remaining()
+	if i := strings.IndexAny(word, " \t"); i >= 0 {
+		word = word[:i]
+	}
+
+	return node.literal(word) == nil
+}
+
+func (node *CommandNode) suggestChildren(prefix string, ctx SuggestionContext) []string {
+	candidates := make([]string, 0)
+
+	for _, child := range node.Children {
+		switch child.Type {
+		case LiteralArgument:
+			candidates = append(candidates, child.Name)
+		case PlayersArgument, GameProfileArgument:
+			candidates = append(candidates, ctx.PlayerNames()...)
+			candidates = append(candidates, "@a", "@p", "@r", "@s")
+		case EntitiesArgument:
+			candidates = append(candidates, ctx.PlayerNames()...)
+			candidates = append(candidates, "@a", "@e", "@p", "@r", "@s")
+		}
+	}
+
+	matches := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,142 @@
+package mc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WhitelistFile is the name of the file that stores whitelisted players.
+const WhitelistFile = "whitelist.json"
+
+var wl = &whitelist{path: WhitelistFile}
+
+// whitelist is the list of players allowed to join the server when the
+// white-list property is enabled.
+type whitelist struct {
+	mu      sync.RWMutex
+	path    string
+	players []*Player
+}
+
+// LoadWhitelist loads the whitelist.json file, creating it if it does not
+// exist.
+func LoadWhitelist() error {
+	if err := wl.Load(); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+
+		return wl.Save()
+	}
+
+	return nil
+}
+
+// Whitelist returns the current server whitelist.
+func Whitelist() *whitelist {
+	return wl
+}
+
+// Load replaces the contents of the whitelist with the contents of its file.
+func (w *whitelist) Load() error {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	players := make([]*Player, 0)
+	if err := json.Unmarshal(data, &players); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.players = players
+	logger.WithField("count", len(players)).Debug("Loaded whitelisted players")
+
+	return nil
+}
+
+// Save writes the contents of the whitelist to its file.
+func (w *whitelist) Save() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	players := w.players
+	if players == nil {
+		players = make([]*Player, 0)
+	}
+
+	data, err := json.MarshalIndent(players, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(w.path, data, 0644)
+}
+
+// Add adds a player to the whitelist and returns false if the player was
+// already whitelisted.
+func (w *whitelist) Add(player *Player) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.index(player) >= 0 {
+		return false
+	}
+
+	w.players = append(w.players, player)
+	return true
+}
+
+// Remove removes a player from the whitelist and returns false if the player
+// was not whitelisted.
+func (w *whitelist) Remove(player *Player) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.index(player)
+	if i < 0 {
+		return false
+	}
+
+	w.players = append(w.players[:i], w.players[i+1:]...)
+	return true
+}
+
+// Contains reports whether the player is whitelisted.
+func (w *whitelist) Contains(player *Player) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.index(player) >= 0
+}
+
+// Names returns the sorted names of all whitelisted players.
+func (w *whitelist) Names() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	names := make([]string, len(w.players))
+	for i, player := range w.players {
+		names[i] = player.Name
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (w *whitelist) index(player *Player) int {
+	for i, p := range w.players {
+		if p.UUID == player.UUID || strings.EqualFold(p.Name, player.Name) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,190 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Version is the protocol version number of the supported Minecraft release.
+const Version = 498
+
+// MinecraftVersion is the supported Minecraft release.
+const MinecraftVersion = "1.14.4"
+
+// MaxPacketLength is the maximum length of an uncompressed packet accepted from
+// a client.
+const MaxPacketLength = 2097151
+
+// State is the state of a Minecraft connection, which determines how incoming
+// packet IDs are interpreted.
+type State int32
+
+const (
+	// Handshaking is the initial state of every connection.
+	Handshaking State = iota
+
+	// Status is the state of a connection requesting server list information.
+	Status
+
+	// Login is the state of a connection logging in to the server.
+	Login
+
+	// Play is the state of a logged in player's connection.
+	Play
+)
+
+// String maps State values to their string names.
+func (state State) String() string {
+	switch state {
+	case Handshaking:
+		return "Handshaking"
+	case Status:
+		return "Status"
+	case Login:
+		return "Login"
+	case Play:
+		return "Play"
+	default:
+		return ""
+	}
+}
+
+// Packet IDs used by the server.
+const (
+	HandshakePacket int32 = 0x00
+
+	StatusRequestPacket  int32 = 0x00
+	StatusResponsePacket int32 = 0x00
+	PingPacket           int32 = 0x01
+	PongPacket           int32 = 0x01
+
+	LoginStartPacket      int32 = 0x00
+	LoginDisconnectPacket int32 = 0x00
+	LoginSuccessPacket    int32 = 0x02
+
+	TabCompleteRequestPacket        int32 = 0x06
+	ChatMessagePacket               int32 = 0x0e
+	TabCompleteResponsePacket       int32 = 0x10
+	DeclareCommandsPacket           int32 = 0x11
+	PlayerPositionPacket            int32 = 0x11
+	PlayerPositionAndRotationPacket int32 = 0x12
+	PlayDisconnectPacket            int32 = 0x1a
+)
+
+// Packet is an uncompressed Minecraft protocol packet.
+type Packet struct {
+	ID int32
+	*bytes.Buffer
+}
+
+// NewPacket creates an empty packet with the given ID.
+func NewPacket(id int32) *Packet {
+	return &Packet{
+		ID:     id,
+		Buffer: new(bytes.Buffer),
+	}
+}
+
+// ReadPacket reads a single length-prefixed packet from the reader.
+func ReadPacket(r *bufio.Reader) (*Packet, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if length < 1 || length > MaxPacketLength {
+		return nil, fmt.Errorf("Packet length of %d is out of range", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	packet := &Packet{Buffer: bytes.NewBuffer(buf)}
+	if packet.ID, err = packet.ReadVarInt(); err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+// Bytes is the length-prefixed byte representation of the packet for writing
+// to the network connection.
+func (packet *Packet) Bytes() []byte {
+	body := AppendVarInt(nil, packet.ID)
+	body = append(body, packet.Buffer.Bytes()...)
+
+	return append(AppendVarInt(nil, int32(len(body))), body...)
+}
+
+// ReadVarInt reads a VarInt field from the packet.
+func (packet *Packet) ReadVarInt() (int32, error) {
+	return ReadVarInt(packet.Buffer)
+}
+
+// ReadString reads a VarInt length-prefixed UTF-8 string field from the packet.
+func (packet *Packet) ReadString() (string, error) {
+	length, err := packet.ReadVarInt()
+	if err != nil {
+		return "", err
+	}
+
+	if length < 0 || int(length) > packet.Len() {
+		return "", fmt.Errorf("String length of %d is out of range", length)
+	}
+
+	return string(packet.Next(int(length))), nil
+}
+
+// ReadUnsignedShort reads a big-endian unsigned 16-bit integer field from the
+// packet.
+func (packet *Packet) ReadUnsignedShort() (uint16, error) {
+	var v uint16
+	err := binary.Read(packet.Buffer, binary.BigEndian, &v)
+	return v, err
+}
+
+// ReadLong reads a big-endian signed 64-bit integer field from the packet.
+func (packet *Packet) ReadLong() (int64, error) {
+	var v int64
+	err := binary.Read(packet.Buffer, binary.BigEndian, &v)
+	return v, err
+}
+
+// ReadDouble reads a big-endian IEEE 754 double-precision floating point field
+// from the packet.
+func (packet *Packet) ReadDouble() (float64, error) {
+	var v float64
+	err := binary.Read(packet.Buffer, binary.BigEndian, &v)
+	return v, err
+}
+
+// WriteBool appends a boolean field to the packet.
+func (packet *Packet) WriteBool(v bool) {
+	if v {
+		packet.WriteByte(1)
+	} else {
+		packet.WriteByte(0)
+	}
+}
+
+// WriteVarInt appends a VarInt field to the packet.
+func (packet *Packet) WriteVarInt(v int32) {
+	packet.Write(AppendVarInt(nil, v))
+}
+
+// WriteString appends a VarInt length-prefixed UTF-8 string field to the
+// packet.
+func (packet *Packet) WriteString(s string) {
+	packet.WriteVarInt(int32(len(s)))
+	packet.Buffer.WriteString(s)
+}
+
+// WriteLong appends a big-endian signed 64-bit integer field to the packet.
+func (packet *Packet) WriteLong(v int64) {
+	_ = binary.Write(packet.Buffer, binary.BigEndian, v)
+}
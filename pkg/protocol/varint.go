@@ -0,0 +1,45 @@
+package protocol
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxVarIntLen is the maximum number of bytes in an encoded VarInt.
+const MaxVarIntLen = 5
+
+// ReadVarInt reads a variable-length little-endian base 128 encoded 32-bit
+// integer from the reader.
+func ReadVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+
+	for i := 0; i < MaxVarIntLen; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		result |= uint32(b&0x7f) << (7 * uint(i))
+
+		if b&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+
+	return 0, fmt.Errorf("VarInt is longer than %d bytes", MaxVarIntLen)
+}
+
+// AppendVarInt appends the variable-length encoding of the value to the byte
+// slice.
+func AppendVarInt(buf []byte, value int32) []byte {
+	v := uint32(value)
+
+	for {
+		if v&^0x7f == 0 {
+			return append(buf, byte(v))
+		}
+
+		buf = append(buf, byte(v&0x7f|0x80))
+		v >>= 7
+	}
+}
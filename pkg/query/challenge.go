@@ -0,0 +1,65 @@
+package query
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+// ChallengeLifetime is how long a challenge token remains valid after it is
+// issued.
+const ChallengeLifetime = 30 * time.Second
+
+// Challenges issues and verifies the challenge tokens of Query clients, which
+// prevent the server from being used to reflect stat responses at spoofed
+// addresses.
+type Challenges struct {
+	tokens map[string]challenge
+	mu     sync.Mutex
+}
+
+type challenge struct {
+	token  int32
+	issued time.Time
+}
+
+// NewChallenges returns an empty set of challenge tokens.
+func NewChallenges() *Challenges {
+	return &Challenges{tokens: make(map[string]challenge)}
+}
+
+// Issue returns a new challenge token for the client address, replacing any
+// previous token. Expired tokens of other clients are removed.
+func (challenges *Challenges) Issue(addr net.Addr) (int32, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+
+	token := int32(binary.BigEndian.Uint32(b[:]))
+	now := time.Now()
+
+	challenges.mu.Lock()
+	defer challenges.mu.Unlock()
+
+	for key, c := range challenges.tokens {
+		if now.Sub(c.issued) > ChallengeLifetime {
+			delete(challenges.tokens, key)
+		}
+	}
+
+	challenges.tokens[addr.String()] = challenge{token: token, issued: now}
+	return token, nil
+}
+
+// Verify reports whether the token is the unexpired challenge token of the
+// client address.
+func (challenges *Challenges) Verify(addr net.Addr, token int32) bool {
+	challenges.mu.Lock()
+	defer challenges.mu.Unlock()
+
+	c, ok := challenges.tokens[addr.String()]
+	return ok && c.token == token && time.Since(c.issued) <= ChallengeLifetime
+}
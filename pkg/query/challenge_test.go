@@ -0,0 +1,125 @@
+package query
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestChallenges(t *testing.T) {
+	challenges := NewChallenges()
+	client := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000}
+	other := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50001}
+
+	if challenges.Verify(client, 0) {
+		t.Error("verified a token that was never issued")
+	}
+
+	token, err := challenges.Issue(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !challenges.Verify(client, token) {
+		t.Error("did not verify the issued token")
+	}
+
+	if !challenges.Verify(client, token) {
+		t.Error("did not verify the issued token a second time")
+	}
+
+	if challenges.Verify(client, token+1) {
+		t.Error("verified the wrong token")
+	}
+
+	if challenges.Verify(other, token) {
+		t.Error("verified the token of another client")
+	}
+
+	next, err := challenges.Issue(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != token && challenges.Verify(client, token) {
+		t.Error("verified a replaced token")
+	}
+
+	if !challenges.Verify(client, next) {
+		t.Error("did not verify the new token")
+	}
+}
+
+func TestChallengesExpire(t *testing.T) {
+	challenges := NewChallenges()
+	client := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000}
+	other := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 50000}
+
+	token, err := challenges.Issue(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := challenges.tokens[client.String()]
+	c.issued = c.issued.Add(-ChallengeLifetime - time.Second)
+	challenges.tokens[client.String()] = c
+
+	if challenges.Verify(client, token) {
+		t.Error("verified an expired token")
+	}
+
+	if _, err := challenges.Issue(other); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := challenges.tokens[client.String()]; ok {
+		t.Error("expired token was not removed when issuing another")
+	}
+}
+
+// TestHandshakeFlow follows a client through a handshake and a full stat
+// request, as a Query client does.
+func TestHandshakeFlow(t *testing.T) {
+	challenges := NewChallenges()
+	client := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000}
+
+	req, err := ParseRequest(unhex(t, "fefd 09 00000007"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := challenges.Issue(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := HandshakeResponse(req.SessionID, token)
+	if resp[0] != byte(HandshakePacket) || resp[4] != 7 || resp[len(resp)-1] != 0 {
+		t.Fatalf("unexpected handshake response % x", resp)
+	}
+
+	// The client parses the token from the decimal string in the response,
+	// and sends it back as a big-endian integer.
+	parsed, err := strconv.ParseInt(string(resp[5:len(resp)-1]), 10, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat := []byte{0xfe, 0xfd, 0x00, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(stat[7:11], uint32(parsed))
+
+	req, err = ParseRequest(stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Type != StatPacket || !req.Full || req.SessionID != 7 {
+		t.Errorf("ParseRequest = %+v, expected a full stat request for session 7", req)
+	}
+
+	if !challenges.Verify(client, req.Token) {
+		t.Errorf("token %d from the handshake response was not verified", req.Token)
+	}
+}
@@ -0,0 +1,167 @@
+// Package query implements the GameSpy4 Query protocol, with which server
+// lists and monitoring tools poll the status of a server over UDP.
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// PacketType is the type of a Query request or response.
+type PacketType byte
+
+const (
+	// StatPacket is a basic or full stat request or response.
+	StatPacket PacketType = 0x00
+
+	// HandshakePacket is a request for a challenge token, or a response
+	// containing one.
+	HandshakePacket PacketType = 0x09
+)
+
+// Lengths of requests, including the magic bytes.
+const (
+	HandshakeLength = 7
+	BasicStatLength = 11
+	FullStatLength  = 15
+)
+
+// GameType and GameID identify the game in stat responses.
+const (
+	GameType = "SMP"
+	GameID   = "MINECRAFT"
+)
+
+// Magic is the prefix of every Query request.
+var Magic = []byte{0xfe, 0xfd}
+
+var (
+	// splitnumPadding precedes the key/value section of a full stat response.
+	splitnumPadding = []byte("splitnum\x00\x80\x00")
+
+	// playerPadding precedes the player section of a full stat response.
+	playerPadding = []byte("\x01player_\x00\x00")
+)
+
+// Request is an incoming Query request.
+type Request struct {
+	Type      PacketType
+	SessionID int32
+	Token     int32
+	Full      bool
+}
+
+// ParseRequest parses and validates a Query request.
+func ParseRequest(data []byte) (*Request, error) {
+	if len(data) < HandshakeLength || !bytes.Equal(data[:2], Magic) {
+		return nil, fmt.Errorf("Invalid Query request")
+	}
+
+	req := &Request{
+		Type:      PacketType(data[2]),
+		SessionID: int32(binary.BigEndian.Uint32(data[3:7])),
+	}
+
+	switch req.Type {
+	case HandshakePacket:
+		return req, nil
+	case StatPacket:
+		if len(data) < BasicStatLength {
+			return nil, fmt.Errorf("Query stat request is too short")
+		}
+
+		req.Token = int32(binary.BigEndian.Uint32(data[7:11]))
+		req.Full = len(data) >= FullStatLength
+
+		return req, nil
+	}
+
+	return nil, fmt.Errorf("Invalid Query packet type %#x", req.Type)
+}
+
+// HandshakeResponse returns the response to a handshake request, containing
+// the challenge token that the client must send with stat requests.
+func HandshakeResponse(sessionID int32, token int32) []byte {
+	buf := header(HandshakePacket, sessionID)
+	writeString(buf, strconv.FormatInt(int64(token), 10))
+
+	return buf.Bytes()
+}
+
+// Stat is the status of a server, as reported in stat responses.
+type Stat struct {
+	MOTD       string
+	Version    string
+	Plugins    string
+	Map        string
+	MaxPlayers int
+	HostIP     string
+	HostPort   int
+	Players    []string
+}
+
+// Basic returns the response to a basic stat request.
+func (stat *Stat) Basic(sessionID int32) []byte {
+	buf := header(StatPacket, sessionID)
+
+	writeString(buf, stat.MOTD)
+	writeString(buf, GameType)
+	writeString(buf, stat.Map)
+	writeString(buf, strconv.Itoa(len(stat.Players)))
+	writeString(buf, strconv.Itoa(stat.MaxPlayers))
+	_ = binary.Write(buf, binary.LittleEndian, uint16(stat.HostPort))
+	writeString(buf, stat.HostIP)
+
+	return buf.Bytes()
+}
+
+// Full returns the response to a full stat request.
+func (stat *Stat) Full(sessionID int32) []byte {
+	buf := header(StatPacket, sessionID)
+	buf.Write(splitnumPadding)
+
+	kv := [][2]string{
+		{"hostname", stat.MOTD},
+		{"gametype", GameType},
+		{"game_id", GameID},
+		{"version", stat.Version},
+		{"plugins", stat.Plugins},
+		{"map", stat.Map},
+		{"numplayers", strconv.Itoa(len(stat.Players))},
+		{"maxplayers", strconv.Itoa(stat.MaxPlayers)},
+		{"hostport", strconv.Itoa(stat.HostPort)},
+		{"hostip", stat.HostIP},
+	}
+
+	for _, pair := range kv {
+		writeString(buf, pair[0])
+		writeString(buf, pair[1])
+	}
+
+	buf.WriteByte(0)
+	buf.Write(playerPadding)
+
+	for _, name := range stat.Players {
+		writeString(buf, name)
+	}
+
+	buf.WriteByte(0)
+
+	return buf.Bytes()
+}
+
+func header(pt PacketType, sessionID int32) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(pt))
+	_ = binary.Write(buf, binary.BigEndian, sessionID)
+
+	return buf
+}
+
+// writeString writes a null-terminated string.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.WriteByte(0)
+}
@@ -0,0 +1,147 @@
+package query
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unhex decodes a hex dump, ignoring whitespace.
+func unhex(t *testing.T, dump string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(strings.Join(strings.Fields(dump), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		dump     string
+		expected *Request
+	}{
+		{"handshake", "fefd 09 00000001", &Request{Type: HandshakePacket, SessionID: 1}},
+		{"handshake with padding", "fefd 09 00000001 00000000", &Request{Type: HandshakePacket, SessionID: 1}},
+		{"basic stat", "fefd 00 00000001 0091295b", &Request{Type: StatPacket, SessionID: 1, Token: 9513307}},
+		{"full stat", "fefd 00 00000001 0091295b 00000000", &Request{Type: StatPacket, SessionID: 1, Token: 9513307, Full: true}},
+		{"negative token", "fefd 00 0f0f0f0f ffffffff", &Request{Type: StatPacket, SessionID: 0x0f0f0f0f, Token: -1}},
+	}
+
+	for _, test := range tests {
+		req, err := ParseRequest(unhex(t, test.dump))
+		if err != nil {
+			t.Errorf("%s: ParseRequest returned error: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(req, test.expected) {
+			t.Errorf("%s: ParseRequest = %+v, expected %+v", test.name, req, test.expected)
+		}
+	}
+}
+
+func TestParseRequestInvalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":             "",
+		"wrong magic":       "fefe 09 00000001",
+		"short handshake":   "fefd 09 000000",
+		"short stat":        "fefd 00 00000001 009129",
+		"unknown type":      "fefd 01 00000001",
+		"legacy ping":       "fe01",
+		"Minecraft packets": "0f00 f205 096c 6f63 616c 686f 7374 63dd 01",
+	}
+
+	for name, dump := range tests {
+		if req, err := ParseRequest(unhex(t, dump)); err == nil {
+			t.Errorf("%s: ParseRequest = %+v, expected an error", name, req)
+		}
+	}
+}
+
+// The expected responses below are the examples of the Query protocol
+// documentation at https://wiki.vg/Query, as sent by the vanilla server.
+
+func TestHandshakeResponse(t *testing.T) {
+	expected := unhex(t, "09 00000001 39353133333037 00")
+
+	if resp := HandshakeResponse(1, 9513307); !bytes.Equal(resp, expected) {
+		t.Errorf("HandshakeResponse = % x, expected % x", resp, expected)
+	}
+
+	if resp := HandshakeResponse(1, -42); !bytes.Equal(resp, unhex(t, "09 00000001 2d3432 00")) {
+		t.Errorf("HandshakeResponse with negative token = % x", resp)
+	}
+}
+
+func testStat() *Stat {
+	return &Stat{
+		MOTD:       "A Minecraft Server",
+		Version:    "Beta 1.9 Prerelease 4",
+		Map:        "world",
+		MaxPlayers: 20,
+		HostIP:     "127.0.0.1",
+		HostPort:   25565,
+		Players:    []string{"barneygale", "Vivalahelvig"},
+	}
+}
+
+func TestBasicStat(t *testing.T) {
+	expected := unhex(t, `
+		00 00000001
+		41204d696e6563726166742053657276657200
+		534d5000
+		776f726c6400
+		3200
+		323000
+		dd63
+		3132372e302e302e3100`)
+
+	if resp := testStat().Basic(1); !bytes.Equal(resp, expected) {
+		t.Errorf("Basic = % x, expected % x", resp, expected)
+	}
+}
+
+func TestFullStat(t *testing.T) {
+	expected := unhex(t, `
+		00 00000001
+		73706c69746e756d00 8000
+		686f73746e616d6500 41204d696e6563726166742053657276657200
+		67616d657479706500 534d5000
+		67616d655f696400 4d494e45435241465400
+		76657273696f6e00 4265746120312e392050726572656c65617365203400
+		706c7567696e7300 00
+		6d617000 776f726c6400
+		6e756d706c617965727300 3200
+		6d6178706c617965727300 323000
+		686f7374706f727400 323535363500
+		686f7374697000 3132372e302e302e3100
+		00
+		01 706c617965725f00 00
+		6261726e657967616c6500
+		566976616c6168656c76696700
+		00`)
+
+	if resp := testStat().Full(1); !bytes.Equal(resp, expected) {
+		t.Errorf("Full = % x, expected % x", resp, expected)
+	}
+}
+
+func TestFullStatNoPlayers(t *testing.T) {
+	stat := testStat()
+	stat.Players = nil
+
+	resp := stat.Full(1)
+	if !bytes.HasSuffix(resp, unhex(t, "00 01 706c617965725f00 00 00")) {
+		t.Errorf("Full with no players ends with % x", resp[len(resp)-12:])
+	}
+
+	if !bytes.Contains(resp, []byte("numplayers\x000\x00")) {
+		t.Errorf("Full with no players does not report 0 players: %q", resp)
+	}
+}
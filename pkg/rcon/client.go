@@ -0,0 +1,145 @@
+package rcon
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DialTimeout is the default timeout for connecting to an RCON server.
+const DialTimeout = 10 * time.Second
+
+// ErrAuthFailed is returned when an RCON server rejects the password sent by
+// a client.
+var ErrAuthFailed = errors.New("Authentication failed")
+
+// Client is a connection to an RCON server for executing commands.
+type Client struct {
+	net.Conn
+	nextID int32
+	mu     sync.Mutex
+}
+
+// Dial connects to the RCON server at the address.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(conn), nil
+}
+
+// DialTLS connects to the RCON server at the address over TLS with the given
+// configuration.
+func DialTLS(addr string, config *tls.Config) (*Client, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: DialTimeout}, "tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client that communicates with an RCON server over the
+// connection.
+func NewClient(conn net.Conn) *Client {
+	return &Client{Conn: conn, nextID: 1}
+}
+
+// Login authenticates with the server. The password is either the
+// rcon.password property of the server, or the name and password of an RCON
+// account separated by a colon. It may be empty if the server accepts the
+// client certificate of a TLS connection instead.
+func (client *Client) Login(password string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	id := client.requestID()
+	if err := client.writePacket(id, AuthRequest, []byte(password)); err != nil {
+		return err
+	}
+
+	for {
+		packet, err := ReadResponse(client.Conn)
+		if err != nil {
+			return err
+		}
+
+		// Some servers send an empty Response packet before the AuthResponse.
+		if packet.Type != AuthResponse {
+			continue
+		}
+
+		if packet.RequestID == -1 {
+			return ErrAuthFailed
+		}
+
+		if packet.RequestID != id {
+			return fmt.Errorf("Unexpected request ID %d in authentication response", packet.RequestID)
+		}
+
+		return nil
+	}
+}
+
+// Execute runs a command on the server and returns its response. Responses
+// that are split across multiple packets are reassembled by following the
+// command with an empty Response packet, which the server echoes back once it
+// has sent the full response to the command.
+func (client *Client) Execute(command string) (string, error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	id := client.requestID()
+	if err := client.writePacket(id, Command, []byte(command)); err != nil {
+		return "", err
+	}
+
+	marker := client.requestID()
+	if err := client.writePacket(marker, Response, nil); err != nil {
+		return "", err
+	}
+
+	response := new(bytes.Buffer)
+	for {
+		packet, err := ReadResponse(client.Conn)
+		if err != nil {
+			return "", err
+		}
+
+		switch packet.RequestID {
+		case id:
+			response.Write(packet.Payload)
+		case marker:
+			return response.String(), nil
+		default:
+			return "", fmt.Errorf("Unexpected request ID %d in command response", packet.RequestID)
+		}
+	}
+}
+
+func (client *Client) requestID() int32 {
+	id := client.nextID
+	client.nextID++
+
+	if client.nextID <= 0 {
+		client.nextID = 1
+	}
+
+	return id
+}
+
+func (client *Client) writePacket(id int32, pt PacketType, payload []byte) error {
+	bytes, err := NewPacket(id, pt, payload).Bytes()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Conn.Write(bytes)
+	return err
+}
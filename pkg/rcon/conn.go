@@ -1,104 +1,183 @@
 package rcon
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/logs"
 	"github.com/jbhannah/gophermine/pkg/mc"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var logger = logs.Component("rcon")
+
+// requestIDField is the name of the field of log entries that contains the
+// request ID of a command.
+const requestIDField = "request_id"
+
 // Conn is an open RCON connection for receiving and responding to commands.
+// Each Command packet is an independent request, and several may run at once.
+// All output written while a command runs is collected, and sent in one or
+// more Response packets with the request ID of the command once it has
+// finished, in the order in which the commands finish.
 type Conn struct {
 	net.Conn
 	*console.Console
-	response chan []byte
-	errors   chan error
+	user    *User
+	pending *pendingRequests
+	mu      sync.Mutex
 }
 
 // NewConn authenticates and opens a console for a new RCON connection.
 func NewConn(ctx context.Context, conn net.Conn) (*Conn, error) {
 	c := &Conn{
-		Conn:     conn,
-		response: make(chan []byte),
-		errors:   make(chan error),
+		Conn:    conn,
+		pending: newPendingRequests(),
+	}
+
+	if err := c.AcceptLogin(); err != nil {
+		return nil, err
 	}
 
-	name := fmt.Sprintf("%s RCON console", conn.RemoteAddr())
-	if con, err := console.NewConsole(ctx, name, c, c); err != nil {
+	name := fmt.Sprintf("%s RCON console (%s)", conn.RemoteAddr(), c.user.Name)
+	if con, err := console.NewRequestConsole(ctx, name, c, c); err != nil {
 		return nil, err
 	} else {
 		c.Console = con
 	}
 
-	return c, c.AcceptLogin()
+	return c, nil
 }
 
-// ReadPacket builds a Packet from an incoming RCON packet.
-func (conn *Conn) ReadPacket() (*Packet, error) {
-	packet := &Packet{}
-	reader := conn.Conn
+// PermissionLevel returns the permission level of the logged in account.
+func (conn *Conn) PermissionLevel() int {
+	return conn.user.Level
+}
 
-	if err := binary.Read(reader, binary.LittleEndian, &packet.Length); err != nil {
-		return nil, err
-	}
+// AllowsCommand reports whether the logged in account may run commands of the
+// given type.
+func (conn *Conn) AllowsCommand(ct mc.CommandType) bool {
+	return conn.user.AllowsCommand(ct)
+}
 
-	if packet.Length < 10 {
-		return nil, fmt.Errorf("RCON packet length of %d is too short", packet.Length)
-	}
+// ReadPacket reads and validates an incoming RCON packet.
+func (conn *Conn) ReadPacket() (*Packet, error) {
+	return ReadPacket(conn.Conn)
+}
 
-	buf := make([]byte, packet.Length)
-	if err := binary.Read(reader, binary.LittleEndian, &buf); err != nil {
-		return nil, err
-	}
+// ReadRequest reads incoming RCON packets until a Command packet is received,
+// and returns a request for its payload. If an error is encountered, the
+// connection stops its running console and is closed.
+//
+// Clients may follow a command with an empty Response packet to detect the
+// end of a response that is split across multiple packets. An empty Response
+// packet with the same request ID is sent back once the responses to all of
+// the commands received before it have been sent.
+func (conn *Conn) ReadRequest() (console.Request, error) {
+	for {
+		packet, err := conn.ReadPacket()
+		if err != nil {
+			return nil, conn.closeWithError(err)
+		}
 
-	packet.RequestID = int32(binary.LittleEndian.Uint32(buf[:4]))
-	packet.Type = PacketType(binary.LittleEndian.Uint32(buf[4:8]))
-	packet.Payload = buf[8 : packet.Length-2]
+		switch packet.Type {
+		case Command:
+			id := packet.RequestID
+			respond := func(output []byte) error {
+				return conn.writeResponse(id, output)
+			}
 
-	return packet, nil
+			return conn.pending.add(conn.Console.Done(), string(packet.Payload), conn.log().WithField(requestIDField, id), respond)
+		case Response:
+			if err := conn.mirror(packet.RequestID); err != nil {
+				return nil, conn.closeWithError(err)
+			}
+		default:
+			return nil, conn.closeWithError(invalidPacket("unexpected %s packet after login", packet.Type))
+		}
+	}
 }
 
-// Read handles incoming RCON messages. If an EOF is encountered, the connection
-// stops its running console. If no error is encountered, a response with the
-// same request ID as the incoming packet is built and sent once a response is
-// written to the connection's console.
-func (conn *Conn) Read(p []byte) (int, error) {
-	packet, err := conn.ReadPacket()
+// closeWithError stops the connection's console, which closes the network
+// connection, and returns the error. If the error is a protocol violation, it
+// is logged and io.EOF is returned to end the console's input.
+func (conn *Conn) closeWithError(err error) error {
+	defer conn.Console.Stop()
 
-	if err == io.EOF {
-		defer conn.Console.Stop()
-		return 0, err
-	} else if err != nil {
-		return 0, err
+	// Reads from TLS connections are interrupted by a deadline when the
+	// server stops, rather than reaching the end of the input.
+	if conn.Console.Err() != nil {
+		return io.EOF
 	}
 
-	if err := packet.ValidateType(Command); err != nil {
-		return 0, err
+	if _, ok := err.(*InvalidPacketError); !ok {
+		return err
+	}
+
+	conn.log().WithError(err).Warn("Closing RCON connection after invalid packet")
+	return io.EOF
+}
+
+// mirror sends an empty Response packet with the request ID once all pending
+// requests have been responded to.
+func (conn *Conn) mirror(id int32) error {
+	waits := conn.pending.wait()
+	if len(waits) == 0 {
+		_, err := conn.WritePacket(id, Response, nil)
+		return err
 	}
 
 	go func() {
-		_, err := conn.WritePacket(packet.RequestID, Response, <-conn.response)
-		conn.errors <- err
-	}()
+		for _, done := range waits {
+			select {
+			case <-done:
+			case <-conn.Console.Done():
+				return
+			}
+		}
 
-	buf := new(bytes.Buffer)
-	buf.Write(packet.Payload)
-	buf.WriteString("\n")
+		if _, err := conn.WritePacket(id, Response, nil); err != nil && conn.Console.Err() == nil {
+			conn.log().WithError(err).Error("Error responding to RCON client")
+		}
+	}()
 
-	n := copy(p, buf.Bytes())
-	return n, nil
+	return nil
 }
 
 // WritePacket builds an RCON packet and writes it to the underlying network
 // connection.
 func (conn *Conn) WritePacket(id int32, pt PacketType, payload []byte) (int, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	return conn.writePacket(id, pt, payload)
+}
+
+// writeResponse sends the output of a command, split into Response packets of
+// at most MaxResponsePayload bytes, without any other packets in between. An
+// empty Response packet is sent if there was no output.
+func (conn *Conn) writeResponse(id int32, output []byte) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	for _, payload := range splitPayload(output, MaxResponsePayload) {
+		if _, err := conn.writePacket(id, Response, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (conn *Conn) writePacket(id int32, pt PacketType, payload []byte) (int, error) {
 	packet := NewPacket(id, pt, payload)
 
 	bytes, err := packet.Bytes()
@@ -109,18 +188,35 @@ func (conn *Conn) WritePacket(id int32, pt PacketType, payload []byte) (int, err
 	return conn.Conn.Write(bytes)
 }
 
-// Write logs responses to commands sent via the RCON connection, passes the
-// response payload to response channel, and returns any errors encountered by
-// the writing of the packet to the underlying network connection.
+// Write logs output written to the console that is not for any particular
+// request. The RCON protocol has no way to send it to the client, so it is
+// otherwise discarded.
 func (conn *Conn) Write(p []byte) (int, error) {
-	log.Info(string(p))
-	conn.response <- p
-	return len(p), <-conn.errors
+	if len(p) > 0 {
+		conn.log().Debug(string(p))
+	}
+
+	return len(p), nil
 }
 
-// AcceptLogin reads an AuthRequest RCON packet and validates its payload
-// against the RCON password configured in the server.properties file.
+// RemoteAddr returns the network address of the RCON client.
+func (conn *Conn) RemoteAddr() net.Addr {
+	return conn.Conn.RemoteAddr()
+}
+
+func (conn *Conn) log() *log.Entry {
+	return logger.WithField(logs.RemoteAddrField, conn.RemoteAddr().String())
+}
+
+// AcceptLogin reads an AuthRequest RCON packet and logs the client in with its
+// payload and TLS client certificate, if any.
 func (conn *Conn) AcceptLogin() error {
+	if tc, ok := conn.Conn.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			return fmt.Errorf("TLS handshake failed: %v", err)
+		}
+	}
+
 	packet, err := conn.ReadPacket()
 	if err != nil {
 		return err
@@ -130,14 +226,49 @@ func (conn *Conn) AcceptLogin() error {
 		return err
 	}
 
-	if string(packet.Payload) != mc.Properties().RCON.Password {
-		if _, err := conn.WritePacket(-1, AuthResponse, make([]byte, 0)); err != nil {
-			log.Errorf("Error responding to invalid RCON authentication request: %s", err)
-		}
+	var certs []*x509.Certificate
+	if tc, ok := conn.Conn.(*tls.Conn); ok {
+		certs = tc.ConnectionState().PeerCertificates
+	}
 
-		return fmt.Errorf("Incorrect password")
+	user, err := Login(conn.RemoteAddr(), packet.Payload, certs)
+	if err != nil {
+		conn.rejectLogin()
+		return err
 	}
 
+	conn.user = user
+
 	_, err = conn.WritePacket(packet.RequestID, AuthResponse, make([]byte, 0))
 	return err
 }
+
+// rejectLogin responds to an AuthRequest with a failed AuthResponse.
+func (conn *Conn) rejectLogin() {
+	if _, err := conn.WritePacket(-1, AuthResponse, make([]byte, 0)); err != nil {
+		conn.log().WithError(err).Error("Error responding to invalid RCON authentication request")
+	}
+}
+
+// splitPayload splits a response into payloads of at most max bytes, without
+// splitting UTF-8 encoded characters. At least one, possibly empty, payload is
+// always returned.
+func splitPayload(response []byte, max int) [][]byte {
+	payloads := make([][]byte, 0, len(response)/max+1)
+
+	for len(response) > max {
+		n := max
+		for n > 0 && !utf8.RuneStart(response[n]) {
+			n--
+		}
+
+		if n == 0 {
+			n = max
+		}
+
+		payloads = append(payloads, response[:n])
+		response = response[n:]
+	}
+
+	return append(payloads, response)
+}
@@ -0,0 +1,242 @@
+package rcon
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"github.com/jbhannah/gophermine/pkg/runner"
+)
+
+const testPassword = "secret"
+
+// serve accepts RCON connections on a loopback listener, and runs the
+// commands that they receive with the handler. It returns the address of the
+// listener.
+func serve(t *testing.T, handler func(cmd *mc.Command)) string {
+	t.Helper()
+
+	props := mc.Properties()
+	password := props.RCON.Password
+	props.RCON.Password = testPassword
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := make(chan *mc.Command)
+	started := make(chan struct{})
+	close(started)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, mc.ServerCommands, commands)
+	ctx = context.WithValue(ctx, runner.RunnableStarted, started)
+
+	t.Cleanup(func() {
+		cancel()
+		listener.Close()
+		props.RCON.Password = password
+		Lockouts().ClearAll()
+	})
+
+	go func() {
+		for {
+			select {
+			case cmd := <-commands:
+				go func() {
+					handler(cmd)
+					cmd.Finish()
+				}()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				rconn, err := NewConn(ctx, conn)
+				if err != nil {
+					conn.Close()
+					return
+				}
+
+				<-rconn.Start()
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// dial connects a client to the address, which is closed when the test ends.
+func dial(t *testing.T, addr string) *Client {
+	t.Helper()
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// respond writes the output to the command.
+func respond(t *testing.T, cmd *mc.Command, output string) {
+	if _, err := cmd.Write([]byte(output)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	addr := serve(t, func(cmd *mc.Command) {
+		respond(t, cmd, "There are 0 of a max of 20 players online: ")
+	})
+
+	client := dial(t, addr)
+	if err := client.Login(testPassword); err != nil {
+		t.Fatalf("could not log in: %v", err)
+	}
+
+	resp, err := client.Execute("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp != "There are 0 of a max of 20 players online: " {
+		t.Errorf("unexpected response %q", resp)
+	}
+}
+
+func TestLoginFailure(t *testing.T) {
+	addr := serve(t, func(cmd *mc.Command) {
+		t.Errorf("ran command %q without logging in", cmd.Input)
+	})
+
+	client := dial(t, addr)
+	if err := client.Login("incorrect"); err != ErrAuthFailed {
+		t.Fatalf("expected %v, got %v", ErrAuthFailed, err)
+	}
+
+	if _, err := client.Execute("list"); err == nil {
+		t.Error("executed command after failed login")
+	}
+}
+
+func TestExecuteMultiPacketResponse(t *testing.T) {
+	// Multi-byte characters straddle the packet boundaries, and must not be
+	// split between packets.
+	output := strings.Repeat("Gophers ♥ blocks. ", 1000)
+	if len(output) <= 2*MaxResponsePayload {
+		t.Fatalf("output of %d bytes fits in two packets", len(output))
+	}
+
+	addr := serve(t, func(cmd *mc.Command) {
+		respond(t, cmd, output)
+	})
+
+	client := dial(t, addr)
+	if err := client.Login(testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Execute("help")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp != output {
+		t.Errorf("response of %d bytes does not match output of %d bytes", len(resp), len(output))
+	}
+}
+
+func TestExecuteEmptyResponse(t *testing.T) {
+	addr := serve(t, func(cmd *mc.Command) {})
+
+	client := dial(t, addr)
+	if err := client.Login(testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Execute("save-all")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp != "" {
+		t.Errorf("unexpected response %q", resp)
+	}
+}
+
+func TestEndMarker(t *testing.T) {
+	release := make(chan struct{})
+	addr := serve(t, func(cmd *mc.Command) {
+		if cmd.Input == "slow" {
+			<-release
+		}
+
+		respond(t, cmd, cmd.Input)
+	})
+
+	client := dial(t, addr)
+	if err := client.Login(testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	// The marker is only echoed once the response to every command sent
+	// before it has been sent, even if a later command finishes first.
+	if err := client.writePacket(10, Command, []byte("slow")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.writePacket(11, Response, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.writePacket(12, Command, []byte("fast")); err != nil {
+		t.Fatal(err)
+	}
+
+	packet, err := ReadResponse(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if packet.RequestID != 12 || string(packet.Payload) != "fast" {
+		t.Fatalf("expected response to fast command, got %d %q", packet.RequestID, packet.Payload)
+	}
+
+	close(release)
+
+	expected := []struct {
+		id      int32
+		payload string
+	}{
+		{10, "slow"},
+		{11, ""},
+	}
+
+	for _, e := range expected {
+		packet, err := ReadResponse(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if packet.Type != Response || packet.RequestID != e.id || string(packet.Payload) != e.payload {
+			t.Fatalf("expected response %d %q, got %s %d %q", e.id, e.payload, packet.Type, packet.RequestID, packet.Payload)
+		}
+	}
+}
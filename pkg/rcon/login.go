@@ -0,0 +1,58 @@
+package rcon
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrIncorrectPassword is returned when a client fails to log in.
+var ErrIncorrectPassword = errors.New("Incorrect password")
+
+// Login validates the password sent by an RCON client at the address against
+// the RCON password configured in the server.properties file, or the name and
+// password of an account in the rcon-users.json file, and returns the account
+// that the client is logged in as. If certificate logins are enabled, clients
+// with a verified TLS client certificate whose common name is the name of an
+// account are logged in as that account regardless of the password. Clients
+// that are locked out, or that try again too soon after a failed attempt, are
+// rejected without checking the password.
+func Login(addr net.Addr, password []byte, certs []*x509.Certificate) (*User, error) {
+	entry := logger.WithField(logs.RemoteAddrField, addr.String())
+
+	if err := lockouts.Check(addr); err != nil {
+		return nil, err
+	}
+
+	user, ok := authenticate(password, certs)
+	if !ok {
+		if until := lockouts.Fail(addr); !until.IsZero() {
+			entry.WithField("until", until.Format(time.RFC3339)).Warn("Locked out RCON client after too many failed login attempts")
+		}
+
+		return nil, ErrIncorrectPassword
+	}
+
+	lockouts.Succeed(addr)
+	entry.WithFields(log.Fields{"user": user.Name, "permission_level": user.Level}).Info("RCON client logged in")
+
+	return user, nil
+}
+
+// authenticate returns the account named by the verified client certificate of
+// a TLS connection if certificate logins are enabled and the certificate names
+// an account, or the account that matches the password otherwise.
+func authenticate(password []byte, certs []*x509.Certificate) (*User, bool) {
+	if len(certs) > 0 && mc.Properties().RCONCertLogin() {
+		if user := users.AuthenticateCertificate(certs[0]); user != nil {
+			return user, true
+		}
+	}
+
+	return users.Authenticate(password)
+}
@@ -0,0 +1,65 @@
+package rcon
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthenticateCertificate(t *testing.T) {
+	props := mc.Properties()
+	saved := props.RCON
+	props.RCON.Password = testPassword
+	props.RCON.TLS.CertFile = "cert.pem"
+	props.RCON.TLS.KeyFile = "key.pem"
+	props.RCON.TLS.ClientCAFile = "ca.pem"
+	props.RCON.TLS.CertLogin = true
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operator := &User{Name: "operator", Password: string(hash), Level: 2}
+	users.users = []*User{operator}
+
+	t.Cleanup(func() {
+		props.RCON = saved
+		users.users = nil
+	})
+
+	cert := func(cn string) []*x509.Certificate {
+		return []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}}
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		certs    []*x509.Certificate
+		expected *User
+	}{
+		{"known certificate", "", cert("operator"), operator},
+		{"known certificate with wrong password", "wrong", cert("operator"), operator},
+		{"unknown certificate", "", cert("stranger"), nil},
+		{"unknown certificate with wrong password", "wrong", cert("stranger"), nil},
+		{"unknown certificate with rcon.password", testPassword, cert("stranger"), adminUser},
+		{"unknown certificate with account password", "operator:hunter2", cert("admin"), operator},
+		{"no certificate", "", nil, nil},
+		{"no certificate with rcon.password", testPassword, nil, adminUser},
+	}
+
+	for _, test := range tests {
+		user, ok := authenticate([]byte(test.password), test.certs)
+		if user != test.expected || ok != (test.expected != nil) {
+			t.Errorf("%s: logged in as %v (%v), expected %v", test.name, user, ok, test.expected)
+		}
+	}
+
+	props.RCON.TLS.CertLogin = false
+	if user, ok := authenticate(nil, cert("operator")); ok {
+		t.Errorf("logged in as %v with certificate logins disabled", user)
+	}
+}
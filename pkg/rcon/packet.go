@@ -4,8 +4,43 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
+const (
+	// MinPacketLength is the minimum value of the length field of a packet,
+	// for a packet with an empty payload.
+	MinPacketLength = 10
+
+	// MaxRequestSize is the maximum size in bytes of an incoming packet,
+	// including its length field.
+	MaxRequestSize = 1460
+
+	// MaxResponsePayload is the maximum length in bytes of the payload of a
+	// single Response packet. Longer responses are split across multiple
+	// packets.
+	MaxResponsePayload = 4096
+
+	// MaxResponseSize is the maximum size in bytes of a Response packet,
+	// including its length field.
+	MaxResponseSize = 4 + 4 + 4 + MaxResponsePayload + 2
+)
+
+// InvalidPacketError is returned when an incoming packet violates the RCON
+// protocol. Connections that send invalid packets are closed.
+type InvalidPacketError struct {
+	Reason string
+}
+
+func invalidPacket(format string, args ...interface{}) *InvalidPacketError {
+	return &InvalidPacketError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the reason the packet is invalid.
+func (err *InvalidPacketError) Error() string {
+	return fmt.Sprintf("Invalid RCON packet: %s", err.Reason)
+}
+
 // PacketType is the value of the packet type field in an RCON packet.
 type PacketType int32
 
@@ -59,6 +94,74 @@ type Packet struct {
 	Payload []byte
 }
 
+// ReadPacket reads a single incoming packet from the reader, and validates its
+// length, null padding and type. The length is checked before the rest of the
+// packet is read, so that no more than MaxRequestSize bytes are allocated.
+func ReadPacket(reader io.Reader) (*Packet, error) {
+	packet, err := readPacket(reader, MaxRequestSize)
+	if err != nil {
+		return nil, err
+	}
+
+	switch packet.Type {
+	case Response, Command, AuthRequest:
+	default:
+		return nil, invalidPacket("unknown packet type %d", packet.Type)
+	}
+
+	return packet, nil
+}
+
+// ReadResponse reads a single packet sent by a server from the reader, and
+// validates its length, null padding and type.
+func ReadResponse(reader io.Reader) (*Packet, error) {
+	packet, err := readPacket(reader, MaxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+
+	switch packet.Type {
+	case Response, AuthResponse:
+	default:
+		return nil, invalidPacket("unknown packet type %d", packet.Type)
+	}
+
+	return packet, nil
+}
+
+func readPacket(reader io.Reader, maxSize int32) (*Packet, error) {
+	packet := &Packet{}
+
+	if err := binary.Read(reader, binary.LittleEndian, &packet.Length); err != nil {
+		return nil, err
+	}
+
+	if packet.Length < MinPacketLength {
+		return nil, invalidPacket("length of %d is shorter than the minimum of %d", packet.Length, MinPacketLength)
+	}
+
+	if max := maxSize - 4; packet.Length > max {
+		return nil, invalidPacket("length of %d is longer than the maximum of %d", packet.Length, max)
+	}
+
+	buf := make([]byte, packet.Length)
+	if _, err := io.ReadFull(reader, buf); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	if buf[packet.Length-2] != 0 || buf[packet.Length-1] != 0 {
+		return nil, invalidPacket("missing null padding")
+	}
+
+	packet.RequestID = int32(binary.LittleEndian.Uint32(buf[:4]))
+	packet.Type = PacketType(binary.LittleEndian.Uint32(buf[4:8]))
+	packet.Payload = buf[8 : packet.Length-2]
+
+	return packet, nil
+}
+
 // NewPacket builds a packet with calculated length for the given values.
 func NewPacket(id int32, pt PacketType, payload []byte) *Packet {
 	return &Packet{
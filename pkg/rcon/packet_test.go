@@ -0,0 +1,109 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func packetBytes(t testing.TB, id int32, pt PacketType, payload string) []byte {
+	t.Helper()
+
+	data, err := NewPacket(id, pt, []byte(payload)).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func FuzzReadPacket(f *testing.F) {
+	f.Add(packetBytes(f, 1, AuthRequest, "secret"))
+	f.Add(packetBytes(f, 2, Command, "list"))
+	f.Add(packetBytes(f, 3, Response, ""))
+	f.Add(packetBytes(f, 4, Command, "say hi")[:12])
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0x7f})
+
+	noPadding := packetBytes(f, 5, Command, "list")
+	noPadding[len(noPadding)-1] = 'x'
+	f.Add(noPadding)
+
+	unknownType := packetBytes(f, 6, PacketType(7), "list")
+	f.Add(unknownType)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reader := bytes.NewReader(data)
+
+		packet, err := ReadPacket(reader)
+		if err != nil {
+			return
+		}
+
+		if packet.Length < MinPacketLength || packet.Length > MaxRequestSize-4 {
+			t.Fatalf("accepted packet with length %d outside of [%d, %d]", packet.Length, MinPacketLength, MaxRequestSize-4)
+		}
+
+		if n := 4 + 4 + len(packet.Payload) + 2; int(packet.Length) != n {
+			t.Fatalf("length field is %d, but packet is %d bytes long", packet.Length, n)
+		}
+
+		if read := len(data) - reader.Len(); read != int(packet.Length)+4 {
+			t.Fatalf("read %d bytes for packet of length %d", read, packet.Length)
+		}
+
+		switch packet.Type {
+		case Response, Command, AuthRequest:
+		default:
+			t.Fatalf("accepted packet with unknown type %d", packet.Type)
+		}
+	})
+}
+
+func FuzzPacketRoundTrip(f *testing.F) {
+	f.Add(int32(1), int32(AuthRequest), []byte("secret"))
+	f.Add(int32(2), int32(Command), []byte("list"))
+	f.Add(int32(-1), int32(Response), []byte{})
+	f.Add(int32(3), int32(Command), bytes.Repeat([]byte("a"), MaxRequestSize-14))
+	f.Add(int32(4), int32(Command), bytes.Repeat([]byte("a"), MaxRequestSize-13))
+	f.Add(int32(5), int32(9), []byte("list"))
+
+	f.Fuzz(func(t *testing.T, id int32, pt int32, payload []byte) {
+		sent := NewPacket(id, PacketType(pt), payload)
+
+		data, err := sent.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if length := int32(binary.LittleEndian.Uint32(data)); length != sent.Length || int(length)+4 != len(data) {
+			t.Fatalf("length field is %d for %d bytes", length, len(data))
+		}
+
+		received, err := ReadPacket(bytes.NewReader(data))
+
+		valid := len(data) <= MaxRequestSize
+		switch sent.Type {
+		case Response, Command, AuthRequest:
+		default:
+			valid = false
+		}
+
+		if !valid {
+			if err == nil {
+				t.Fatalf("accepted invalid packet %+v", sent)
+			}
+
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("could not read packet %+v: %v", sent, err)
+		}
+
+		if received.Length != sent.Length || received.RequestID != sent.RequestID ||
+			received.Type != sent.Type || !bytes.Equal(received.Payload, sent.Payload) {
+			t.Fatalf("read %+v, expected %+v", received, sent)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package rcon
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxPendingRequests is the maximum number of commands from a single RCON
+// connection that may run at once. No more commands are read from the
+// connection until one of them has finished.
+const MaxPendingRequests = 16
+
+// request is a command received from an RCON client, which collects the
+// output of the command until it is sent in response.
+type request struct {
+	line    string
+	output  *bytes.Buffer
+	respond func([]byte) error
+	pending *pendingRequests
+	log     *log.Entry
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+// pendingRequests tracks the commands from an RCON connection that have not
+// yet been responded to.
+type pendingRequests struct {
+	requests map[*request]struct{}
+	slots    chan struct{}
+	mu       sync.Mutex
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		requests: make(map[*request]struct{}),
+		slots:    make(chan struct{}, MaxPendingRequests),
+	}
+}
+
+// add waits until fewer than MaxPendingRequests requests are pending, and
+// registers a request for the command, whose output is passed to respond once
+// it has finished. It returns io.EOF if stop is closed while waiting.
+func (pending *pendingRequests) add(stop <-chan struct{}, line string, entry *log.Entry, respond func([]byte) error) (*request, error) {
+	select {
+	case pending.slots <- struct{}{}:
+	case <-stop:
+		return nil, io.EOF
+	}
+
+	req := &request{
+		line:    line,
+		output:  new(bytes.Buffer),
+		respond: respond,
+		pending: pending,
+		log:     entry,
+		done:    make(chan struct{}),
+	}
+
+	pending.mu.Lock()
+	pending.requests[req] = struct{}{}
+	pending.mu.Unlock()
+
+	return req, nil
+}
+
+// wait returns channels that close when each of the currently pending
+// requests has been responded to.
+func (pending *pendingRequests) wait() []<-chan struct{} {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	waits := make([]<-chan struct{}, 0, len(pending.requests))
+	for req := range pending.requests {
+		waits = append(waits, req.done)
+	}
+
+	return waits
+}
+
+func (pending *pendingRequests) finish(req *request) {
+	pending.mu.Lock()
+	delete(pending.requests, req)
+	pending.mu.Unlock()
+
+	close(req.done)
+	<-pending.slots
+}
+
+// Line returns the command.
+func (req *request) Line() string {
+	return req.line
+}
+
+// Write collects output for the response to the command. Separate writes are
+// separated by newlines in the response.
+func (req *request) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	req.log.Debug(string(p))
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+
+	if req.output.Len() > 0 {
+		req.output.WriteByte('\n')
+	}
+
+	return req.output.Write(p)
+}
+
+// Respond sends the output of the command to the client, and marks the
+// request as finished.
+func (req *request) Respond() error {
+	defer req.pending.finish(req)
+
+	req.mu.Lock()
+	output := req.output.Bytes()
+	req.mu.Unlock()
+
+	return req.respond(output)
+}
@@ -0,0 +1,233 @@
+package rcon
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// BaseLoginDelay is the time a client must wait after its first failed
+	// login attempt before trying again. The delay doubles with each
+	// subsequent failure.
+	BaseLoginDelay = time.Second
+
+	// MaxLoginDelay is the longest time a client must wait between failed
+	// login attempts before it is locked out.
+	MaxLoginDelay = 30 * time.Second
+)
+
+var lockouts = &Throttle{
+	MaxAttempts: 5,
+	Lockout:     5 * time.Minute,
+	clients:     make(map[string]*loginAttempts),
+}
+
+// Throttle tracks failed login attempts by client IP address. Clients must
+// wait exponentially longer after each failed attempt, and are locked out
+// after too many.
+type Throttle struct {
+	MaxAttempts int
+	Lockout     time.Duration
+	clients     map[string]*loginAttempts
+	mu          sync.Mutex
+}
+
+type loginAttempts struct {
+	failures    int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// Lockout is a client IP address that is locked out of RCON.
+type Lockout struct {
+	Host  string
+	Until time.Time
+}
+
+// ThrottleError is returned when a client attempts to log in before its
+// backoff delay or lockout has expired.
+type ThrottleError struct {
+	Host      string
+	Until     time.Time
+	LockedOut bool
+}
+
+// Error describes how long the client must wait.
+func (err *ThrottleError) Error() string {
+	wait := time.Until(err.Until).Round(time.Second)
+
+	if err.LockedOut {
+		return fmt.Sprintf("%s is locked out for %s after too many failed login attempts", err.Host, wait)
+	}
+
+	return fmt.Sprintf("%s must wait %s before trying to log in again", err.Host, wait)
+}
+
+// Lockouts returns the login throttle of the RCON server.
+func Lockouts() *Throttle {
+	return lockouts
+}
+
+// Configure sets the number of failed login attempts after which clients are
+// locked out, and for how long.
+func (throttle *Throttle) Configure(maxAttempts int, lockout time.Duration) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	throttle.MaxAttempts = maxAttempts
+	throttle.Lockout = lockout
+}
+
+// Check returns a ThrottleError if the client is locked out or must wait
+// before attempting to log in again.
+func (throttle *Throttle) Check(addr net.Addr) error {
+	host := hostOf(addr)
+	now := time.Now()
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	attempts, ok := throttle.clients[host]
+	if !ok {
+		return nil
+	}
+
+	if now.Before(attempts.lockedUntil) {
+		return &ThrottleError{Host: host, Until: attempts.lockedUntil, LockedOut: true}
+	}
+
+	if until := attempts.last.Add(backoff(attempts.failures)); now.Before(until) {
+		return &ThrottleError{Host: host, Until: until}
+	}
+
+	return nil
+}
+
+// Fail records a failed login attempt by the client, and returns the time
+// until which it is locked out, or the zero time if it is not.
+func (throttle *Throttle) Fail(addr net.Addr) time.Time {
+	host := hostOf(addr)
+	now := time.Now()
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	throttle.prune(now)
+
+	attempts, ok := throttle.clients[host]
+	if !ok || (!attempts.lockedUntil.IsZero() && now.After(attempts.lockedUntil)) || now.Sub(attempts.last) > throttle.Lockout {
+		attempts = &loginAttempts{}
+		throttle.clients[host] = attempts
+	}
+
+	attempts.failures++
+	attempts.last = now
+
+	if throttle.MaxAttempts > 0 && attempts.failures >= throttle.MaxAttempts {
+		attempts.lockedUntil = now.Add(throttle.Lockout)
+	}
+
+	return attempts.lockedUntil
+}
+
+// prune removes the clients whose failed login attempts have expired, so that
+// clients that never log in successfully are not tracked forever. It must be
+// called with the lock held.
+func (throttle *Throttle) prune(now time.Time) {
+	for host, attempts := range throttle.clients {
+		expired := now.Sub(attempts.last) > throttle.Lockout && now.Sub(attempts.last) > backoff(attempts.failures)
+		if expired && !now.Before(attempts.lockedUntil) {
+			delete(throttle.clients, host)
+		}
+	}
+}
+
+// Succeed clears the failed login attempts of the client.
+func (throttle *Throttle) Succeed(addr net.Addr) {
+	throttle.Clear(hostOf(addr))
+}
+
+// Clear removes the lockout and failed login attempts of the client IP
+// address, and returns false if there were none.
+func (throttle *Throttle) Clear(host string) bool {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	_, ok := throttle.clients[host]
+	delete(throttle.clients, host)
+
+	return ok
+}
+
+// ClearAll removes all lockouts and failed login attempts, and returns the
+// number of clients that were locked out.
+func (throttle *Throttle) ClearAll() int {
+	now := time.Now()
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	n := len(throttle.lockedOut(now))
+	throttle.clients = make(map[string]*loginAttempts)
+
+	return n
+}
+
+// List returns the clients that are currently locked out, sorted by address.
+func (throttle *Throttle) List() []Lockout {
+	now := time.Now()
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	list := throttle.lockedOut(now)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Host < list[j].Host
+	})
+
+	return list
+}
+
+// lockedOut returns the clients that are locked out at the given time. It must
+// be called with the lock held.
+func (throttle *Throttle) lockedOut(now time.Time) []Lockout {
+	list := make([]Lockout, 0)
+	for host, attempts := range throttle.clients {
+		if now.Before(attempts.lockedUntil) {
+			list = append(list, Lockout{Host: host, Until: attempts.lockedUntil})
+		}
+	}
+
+	return list
+}
+
+// backoff returns the delay required after the given number of consecutive
+// failed login attempts.
+func backoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := BaseLoginDelay
+	for i := 1; i < failures && delay < MaxLoginDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > MaxLoginDelay {
+		return MaxLoginDelay
+	}
+
+	return delay
+}
+
+func hostOf(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
@@ -0,0 +1,280 @@
+package rcon
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{-1, 0},
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 16 * time.Second},
+		{6, MaxLoginDelay},
+		{7, MaxLoginDelay},
+		{100, MaxLoginDelay},
+	}
+
+	for _, test := range tests {
+		if delay := backoff(test.failures); delay != test.delay {
+			t.Errorf("backoff(%d) = %s, expected %s", test.failures, delay, test.delay)
+		}
+	}
+}
+
+func newTestThrottle(maxAttempts int, lockout time.Duration) *Throttle {
+	return &Throttle{
+		MaxAttempts: maxAttempts,
+		Lockout:     lockout,
+		clients:     make(map[string]*loginAttempts),
+	}
+}
+
+// elapse moves the failed login attempts tracked by the throttle back in time,
+// as if the duration had passed.
+func elapse(throttle *Throttle, d time.Duration) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	for _, attempts := range throttle.clients {
+		attempts.last = attempts.last.Add(-d)
+		if !attempts.lockedUntil.IsZero() {
+			attempts.lockedUntil = attempts.lockedUntil.Add(-d)
+		}
+	}
+}
+
+func testAddr(host string) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(host), Port: 50000}
+}
+
+// throttleStep is an action on a throttle, followed by the expected state of
+// the client at the address.
+type throttleStep struct {
+	action  string
+	host    string
+	elapsed time.Duration
+
+	// waiting and lockedOut are the expected result of Check for the host
+	// after the action.
+	waiting   bool
+	lockedOut bool
+
+	// list is the expected result of List after the action.
+	list []string
+}
+
+func TestThrottleSequences(t *testing.T) {
+	const a, b = "192.0.2.1", "192.0.2.2"
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		lockout     time.Duration
+		steps       []throttleStep
+	}{
+		{"backoff expires", 3, time.Minute, []throttleStep{
+			{action: "check", host: a},
+			{action: "fail", host: a, waiting: true},
+			{action: "elapse", host: a, elapsed: 999 * time.Millisecond, waiting: true},
+			{action: "elapse", host: a, elapsed: 2 * time.Millisecond},
+			{action: "fail", host: a, waiting: true},
+			{action: "elapse", host: a, elapsed: 1500 * time.Millisecond, waiting: true},
+			{action: "elapse", host: a, elapsed: time.Second},
+		}},
+		{"lockout", 3, time.Minute, []throttleStep{
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, lockedOut: true, list: []string{a}},
+			{action: "check", host: b, list: []string{a}},
+			{action: "elapse", host: a, elapsed: 59 * time.Second, lockedOut: true, list: []string{a}},
+			{action: "elapse", host: a, elapsed: 2 * time.Second},
+			{action: "fail", host: a, waiting: true},
+		}},
+		{"success clears failures", 3, time.Minute, []throttleStep{
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "succeed", host: a},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+		}},
+		{"failures expire", 3, time.Minute, []throttleStep{
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "elapse", host: a, elapsed: 2 * time.Minute},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+		}},
+		{"clients are tracked separately", 2, time.Minute, []throttleStep{
+			{action: "fail", host: b, waiting: true},
+			{action: "fail", host: b, lockedOut: true, list: []string{b}},
+			{action: "fail", host: a, waiting: true, list: []string{b}},
+			{action: "fail", host: a, lockedOut: true, list: []string{a, b}},
+			{action: "clear", host: b, list: []string{a}},
+			{action: "clearall", host: a},
+		}},
+		{"lockouts disabled", 0, time.Minute, []throttleStep{
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "fail", host: a, waiting: true},
+			{action: "elapse", host: a, elapsed: 29 * time.Second, waiting: true},
+			{action: "elapse", host: a, elapsed: 2 * time.Second},
+		}},
+	}
+
+	for _, test := range tests {
+		throttle := newTestThrottle(test.maxAttempts, test.lockout)
+
+		for i, step := range test.steps {
+			addr := testAddr(step.host)
+
+			switch step.action {
+			case "check":
+			case "fail":
+				throttle.Fail(addr)
+			case "succeed":
+				throttle.Succeed(addr)
+			case "clear":
+				throttle.Clear(step.host)
+			case "clearall":
+				throttle.ClearAll()
+			case "elapse":
+				elapse(throttle, step.elapsed)
+			}
+
+			err := throttle.Check(addr)
+			terr, _ := err.(*ThrottleError)
+
+			waiting := terr != nil && !terr.LockedOut
+			lockedOut := terr != nil && terr.LockedOut
+			if err != nil && terr == nil || waiting != step.waiting || lockedOut != step.lockedOut {
+				t.Errorf("%s: step %d (%s %s): Check() = %v, expected waiting %v and locked out %v",
+					test.name, i, step.action, step.host, err, step.waiting, step.lockedOut)
+			}
+
+			hosts := make([]string, 0)
+			for _, lockout := range throttle.List() {
+				hosts = append(hosts, lockout.Host)
+			}
+
+			expected := step.list
+			if expected == nil {
+				expected = []string{}
+			}
+
+			if !reflect.DeepEqual(hosts, expected) {
+				t.Errorf("%s: step %d (%s %s): List() = %v, expected %v", test.name, i, step.action, step.host, hosts, expected)
+			}
+		}
+	}
+}
+
+func TestThrottleFailLockedUntil(t *testing.T) {
+	throttle := newTestThrottle(2, time.Minute)
+	addr := testAddr("192.0.2.1")
+
+	if until := throttle.Fail(addr); !until.IsZero() {
+		t.Errorf("first failure locked out until %s", until)
+	}
+
+	before := time.Now()
+	until := throttle.Fail(addr)
+	if until.Before(before.Add(time.Minute)) || until.After(time.Now().Add(time.Minute)) {
+		t.Errorf("second failure locked out until %s, expected a minute from now", until)
+	}
+
+	if lockouts := throttle.List(); len(lockouts) != 1 || !lockouts[0].Until.Equal(until) {
+		t.Errorf("List() = %v, expected a lockout until %s", lockouts, until)
+	}
+}
+
+func TestThrottlePrune(t *testing.T) {
+	throttle := newTestThrottle(5, time.Minute)
+
+	throttle.Fail(testAddr("192.0.2.1"))
+	throttle.Fail(testAddr("192.0.2.2"))
+	elapse(throttle, 2*time.Minute)
+	throttle.Fail(testAddr("192.0.2.3"))
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	if len(throttle.clients) != 1 || throttle.clients["192.0.2.3"] == nil {
+		t.Errorf("expired clients were not pruned: %v", throttle.clients)
+	}
+}
+
+func TestThrottleClear(t *testing.T) {
+	throttle := newTestThrottle(1, time.Minute)
+
+	if throttle.Clear("192.0.2.1") {
+		t.Error("Clear reported clearing an unknown client")
+	}
+
+	throttle.Fail(testAddr("192.0.2.1"))
+	throttle.Fail(testAddr("192.0.2.2"))
+	throttle.Configure(5, time.Minute)
+	throttle.Fail(testAddr("192.0.2.3"))
+
+	if !throttle.Clear("192.0.2.1") {
+		t.Error("Clear did not report clearing a locked out client")
+	}
+
+	if n := throttle.ClearAll(); n != 1 {
+		t.Errorf("ClearAll() = %d, expected 1 locked out client", n)
+	}
+
+	if n := throttle.ClearAll(); n != 0 {
+		t.Errorf("ClearAll() = %d after clearing", n)
+	}
+
+	if err := throttle.Check(testAddr("192.0.2.3")); err != nil {
+		t.Errorf("Check() = %v after clearing", err)
+	}
+}
+
+func TestThrottleClearAllConcurrent(t *testing.T) {
+	throttle := newTestThrottle(1, time.Minute)
+	wg := &sync.WaitGroup{}
+
+	total := 0
+	totalMu := sync.Mutex{}
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			throttle.Fail(&net.TCPAddr{IP: net.IPv4(192, 0, 2, byte(i)), Port: 50000})
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			n := throttle.ClearAll()
+			totalMu.Lock()
+			total += n
+			totalMu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	total += throttle.ClearAll()
+
+	if total != 50 {
+		t.Errorf("ClearAll() cleared %d lockouts in total, expected 50", total)
+	}
+}
@@ -0,0 +1,171 @@
+package rcon
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jbhannah/gophermine/pkg/mc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UsersFile is the name of the optional file that stores RCON accounts.
+const UsersFile = "rcon-users.json"
+
+// dummyHash is compared against the passwords of unknown users, so that the
+// time taken to reject a login does not reveal whether the user exists.
+var dummyHash = struct {
+	sync.Once
+	hash []byte
+}{}
+
+var users = &userList{path: UsersFile}
+
+// User is a named RCON account. Its password is stored as a bcrypt hash,
+// which can be generated with e.g. `htpasswd -nbBC 10 "" <password>`. If
+// Commands is not empty, the user may only run the listed commands, in
+// addition to any restrictions due to its permission level.
+type User struct {
+	Name     string   `json:"name"`
+	Password string   `json:"password"`
+	Level    int      `json:"level"`
+	Commands []string `json:"commands,omitempty"`
+}
+
+// adminUser is the account of clients that log in with the rcon.password
+// property, which may run any command.
+var adminUser = &User{Name: "admin", Level: mc.MaxPermissionLevel}
+
+// userList is the list of RCON accounts in addition to the rcon.password
+// property.
+type userList struct {
+	mu    sync.RWMutex
+	path  string
+	users []*User
+}
+
+// LoadUsers loads the rcon-users.json file, if it exists.
+func LoadUsers() error {
+	if err := users.Load(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Users returns the RCON accounts.
+func Users() *userList {
+	return users
+}
+
+// Load replaces the accounts with the contents of their file.
+func (list *userList) Load() error {
+	data, err := ioutil.ReadFile(list.path)
+	if err != nil {
+		return err
+	}
+
+	accounts := make([]*User, 0)
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return fmt.Errorf("Could not parse %s: %s", list.path, err)
+	}
+
+	for _, user := range accounts {
+		if user.Name == "" || user.Password == "" {
+			return fmt.Errorf("Every account in %s must have a name and password", list.path)
+		}
+
+		if user.Level < 0 || user.Level > mc.MaxPermissionLevel {
+			return fmt.Errorf("Invalid permission level %d for %s in %s", user.Level, user.Name, list.path)
+		}
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.users = accounts
+	logger.WithField("count", len(accounts)).Debug("Loaded RCON accounts")
+
+	return nil
+}
+
+// Len returns the number of accounts.
+func (list *userList) Len() int {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	return len(list.users)
+}
+
+// Authenticate returns the account that matches the payload of an AuthRequest
+// packet. The payload is either the rcon.password property, or the name and
+// password of an account separated by a colon.
+func (list *userList) Authenticate(payload []byte) (*User, bool) {
+	if password := mc.Properties().RCON.Password; password != "" {
+		if subtle.ConstantTimeCompare(payload, []byte(password)) == 1 {
+			return adminUser, true
+		}
+	}
+
+	name, password := string(payload), ""
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name, password = name[:i], name[i+1:]
+	}
+
+	user := list.lookup(name)
+	if user == nil {
+		dummyHash.Do(func() {
+			dummyHash.hash, _ = bcrypt.GenerateFromPassword([]byte("gophermine"), bcrypt.DefaultCost)
+		})
+
+		_ = bcrypt.CompareHashAndPassword(dummyHash.hash, []byte(password))
+		return nil, false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, false
+	}
+
+	return user, true
+}
+
+// AuthenticateCertificate returns the account for a verified TLS client
+// certificate whose common name is the name of the account, or nil if it names
+// no account.
+func (list *userList) AuthenticateCertificate(cert *x509.Certificate) *User {
+	return list.lookup(cert.Subject.CommonName)
+}
+
+func (list *userList) lookup(name string) *User {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	for _, user := range list.users {
+		if user.Name == name {
+			return user
+		}
+	}
+
+	return nil
+}
+
+// AllowsCommand reports whether the command allowlist of the user, if any,
+// includes commands of the given type.
+func (user *User) AllowsCommand(ct mc.CommandType) bool {
+	if len(user.Commands) == 0 {
+		return true
+	}
+
+	for _, name := range user.Commands {
+		if strings.TrimPrefix(name, "/") == ct.String() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package rcon
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jbhannah/gophermine/pkg/console"
+	"github.com/jbhannah/gophermine/pkg/logs"
+	"github.com/jbhannah/gophermine/pkg/mc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WebSocketCloseTimeout is how long to wait to send a close message to a
+// WebSocket RCON client before closing its connection.
+const WebSocketCloseTimeout = time.Second
+
+// WebSocketRequest is a JSON message sent by a WebSocket RCON client. The
+// first message must contain a password, which is checked the same way as the
+// payload of an AuthRequest packet, and every subsequent message a command.
+type WebSocketRequest struct {
+	ID       int64  `json:"id"`
+	Password string `json:"password,omitempty"`
+	Command  string `json:"command,omitempty"`
+}
+
+// WebSocketResponse is a JSON message sent to a WebSocket RCON client in
+// response to the request with the same ID. Successful logins are answered
+// with the name of the account that the client is logged in as.
+type WebSocketResponse struct {
+	ID       int64  `json:"id"`
+	Response string `json:"response,omitempty"`
+	User     string `json:"user,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// WebSocketConn is an open WebSocket RCON connection, which receives commands
+// and sends their responses as JSON messages. Like Conn, several commands may
+// run at once, and each is responded to as soon as it has finished.
+type WebSocketConn struct {
+	*console.Console
+	ws      *websocket.Conn
+	user    *User
+	pending *pendingRequests
+	mu      sync.Mutex
+}
+
+// NewWebSocketConn authenticates and opens a console for a new WebSocket RCON
+// connection. The certificates are the verified client certificates of the
+// underlying TLS connection, if any.
+func NewWebSocketConn(ctx context.Context, ws *websocket.Conn, certs []*x509.Certificate) (*WebSocketConn, error) {
+	c := &WebSocketConn{
+		ws:      ws,
+		pending: newPendingRequests(),
+	}
+
+	ws.SetReadLimit(MaxRequestSize)
+
+	if err := c.AcceptLogin(certs); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s WebSocket RCON console (%s)", ws.RemoteAddr(), c.user.Name)
+	if con, err := console.NewRequestConsole(ctx, name, c, c); err != nil {
+		return nil, err
+	} else {
+		c.Console = con
+	}
+
+	return c, nil
+}
+
+// AcceptLogin reads the first request of the client, and logs the client in
+// with its password and TLS client certificate, if any.
+func (conn *WebSocketConn) AcceptLogin(certs []*x509.Certificate) error {
+	req := &WebSocketRequest{}
+	if err := conn.ws.ReadJSON(req); err != nil {
+		return err
+	}
+
+	user, err := Login(conn.RemoteAddr(), []byte(req.Password), certs)
+	if err != nil {
+		if werr := conn.ws.WriteJSON(&WebSocketResponse{ID: req.ID, Error: err.Error()}); werr != nil {
+			conn.log().WithError(werr).Error("Error responding to invalid RCON authentication request")
+		}
+
+		return err
+	}
+
+	conn.user = user
+	return conn.ws.WriteJSON(&WebSocketResponse{ID: req.ID, User: user.Name})
+}
+
+// PermissionLevel returns the permission level of the logged in account.
+func (conn *WebSocketConn) PermissionLevel() int {
+	return conn.user.Level
+}
+
+// AllowsCommand reports whether the logged in account may run commands of the
+// given type.
+func (conn *WebSocketConn) AllowsCommand(ct mc.CommandType) bool {
+	return conn.user.AllowsCommand(ct)
+}
+
+// ReadRequest reads messages until one with a command is received, and returns
+// a request for the command. Malformed messages are answered with an error. If
+// the connection is closed or cannot be read, the console stops.
+func (conn *WebSocketConn) ReadRequest() (console.Request, error) {
+	for {
+		msg := &WebSocketRequest{}
+		if err := conn.ws.ReadJSON(msg); err != nil {
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				if werr := conn.writeJSON(&WebSocketResponse{ID: msg.ID, Error: fmt.Sprintf("Invalid request: %v", err)}); werr != nil {
+					return nil, conn.closeWithError(werr)
+				}
+
+				continue
+			default:
+				return nil, conn.closeWithError(err)
+			}
+		}
+
+		if msg.Command == "" {
+			if err := conn.writeJSON(&WebSocketResponse{ID: msg.ID, Error: "Invalid request: missing command"}); err != nil {
+				return nil, conn.closeWithError(err)
+			}
+
+			continue
+		}
+
+		id := msg.ID
+		respond := func(output []byte) error {
+			return conn.writeJSON(&WebSocketResponse{ID: id, Response: string(output)})
+		}
+
+		return conn.pending.add(conn.Console.Done(), msg.Command, conn.log().WithField(requestIDField, id), respond)
+	}
+}
+
+// closeWithError stops the connection's console, which closes the connection,
+// and returns the error, or io.EOF if the client closed the connection or the
+// console is already stopping.
+func (conn *WebSocketConn) closeWithError(err error) error {
+	defer conn.Console.Stop()
+
+	if conn.Console.Err() != nil || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure) {
+		return io.EOF
+	}
+
+	return err
+}
+
+// writeJSON sends a message to the client. Only one message is written at a
+// time.
+func (conn *WebSocketConn) writeJSON(msg *WebSocketResponse) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	return conn.ws.WriteJSON(msg)
+}
+
+// Write logs output written to the console that is not for any particular
+// request, which is otherwise discarded.
+func (conn *WebSocketConn) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		conn.log().Debug(string(p))
+	}
+
+	return len(p), nil
+}
+
+// Close sends a close message to the client and closes the connection.
+func (conn *WebSocketConn) Close() error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(WebSocketCloseTimeout))
+
+	return conn.ws.Close()
+}
+
+// RemoteAddr returns the network address of the RCON client.
+func (conn *WebSocketConn) RemoteAddr() net.Addr {
+	return conn.ws.RemoteAddr()
+}
+
+func (conn *WebSocketConn) log() *log.Entry {
+	return logger.WithField(logs.RemoteAddrField, conn.RemoteAddr().String())
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/jbhannah/gophermine/pkg/logs"
 	log "github.com/sirupsen/logrus"
 )
 
+var logger = logs.Component("runner")
+
 type ContextKey string
 
 const (
@@ -48,14 +51,14 @@ func NewRunner(ctx context.Context, runnable Runnable) *Runner {
 // Start runs the setup steps for the Runnable and starts the looping goroutine,
 // and returns a channel that closes when the runner has started.
 func (runner *Runner) Start() <-chan struct{} {
-	log.Debugf("Starting loop for %s", runner.Name())
+	runner.log().Trace("Starting loop")
 	startTime := time.Now()
 
 	runner.Setup()
 
 	go func() {
 		<-runner.started
-		log.Debugf("Started loop for %s in %s", runner.Name(), time.Since(startTime))
+		runner.log().WithField(logs.DurationField, time.Since(startTime).String()).Trace("Started loop")
 	}()
 
 	go runner.run()
@@ -74,7 +77,7 @@ func (runner *Runner) Started() <-chan struct{} {
 func (runner *Runner) Stop() <-chan struct{} {
 	defer runner.cancel()
 
-	log.Infof("Stop requested for %s", runner.Name())
+	runner.log().Debug("Stop requested")
 	return runner.stopped
 }
 
@@ -95,9 +98,13 @@ func (runner *Runner) run() {
 func (runner *Runner) cleanup() {
 	defer close(runner.stopped)
 
-	log.Debugf("Stopping loop for %s", runner.Name())
+	runner.log().Trace("Stopping loop")
 	stopTime := time.Now()
 
 	runner.Cleanup()
-	log.Debugf("Stopped loop for %s in %s", runner.Name(), time.Since(stopTime))
+	runner.log().WithField(logs.DurationField, time.Since(stopTime).String()).Trace("Stopped loop")
+}
+
+func (runner *Runner) log() *log.Entry {
+	return logger.WithField(logs.NameField, runner.Name())
 }